@@ -0,0 +1,17 @@
+package audit
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Audit() *cobra.Command {
+	audit := &cobra.Command{
+		Use:   "audit",
+		Short: "Check the system for signs of compromise",
+	}
+
+	audit.AddCommand(AuditPreload())
+	audit.AddCommand(AuditProcs())
+
+	return audit
+}
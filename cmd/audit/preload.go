@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"fmt"
+	"seer/pkg/audit"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func printPreloadReport(report audit.PreloadReport, procs map[int]proc.Process) {
+	if len(report.SystemPreload) > 0 {
+		fmt.Printf("WARNING: /etc/ld.so.preload loads %s\n", strings.Join(report.SystemPreload, ", "))
+	} else {
+		fmt.Printf("/etc/ld.so.preload: none\n")
+	}
+	if len(report.Findings) == 0 {
+		fmt.Printf("No processes with preloaded libraries found.\n")
+		return
+	}
+	// Findings are sorted by pid so each process' findings are together
+	for i, f := range report.Findings {
+		if i == 0 || report.Findings[i-1].Pid != f.Pid {
+			fmt.Printf("┬%s", procs[f.Pid].String())
+		}
+		edge := "├"
+		if i == len(report.Findings)-1 || report.Findings[i+1].Pid != f.Pid {
+			edge = "└"
+		}
+		fmt.Printf("%s─ %s: %s\n", edge, f.Source, f.Detail)
+	}
+}
+
+func AuditPreload() *cobra.Command {
+	preload := &cobra.Command{
+		Use:   "preload",
+		Short: "Find libraries preloaded into processes",
+		Long: `Report the libraries in /etc/ld.so.preload along with every process that
+has LD_PRELOAD, LD_LIBRARY_PATH or LD_AUDIT in its environment or has a preloaded
+library mapped into memory. Rootkits such as libprocesshider use these to hook
+every process on the system.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			procs := proc.GetProcesses()
+			report, err := audit.CheckPreload(procs)
+			if err != nil {
+				fmt.Printf("%s\n", err.Error())
+				return
+			}
+			switch output.Get() {
+			case output.Text:
+				printPreloadReport(report, procs)
+			case output.Table:
+				output.PrintTable(report.Findings)
+			default:
+				output.Encode(report)
+			}
+		},
+	}
+
+	return preload
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"fmt"
+	"seer/pkg/audit"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func printFindings(findings []audit.Finding, procs map[int]proc.Process) {
+	if len(findings) == 0 {
+		fmt.Printf("No suspicious processes found.\n")
+		return
+	}
+	// Findings are sorted by pid so each process' findings are together
+	rationale := make(map[string]string)
+	checks := []string{}
+	for i, f := range findings {
+		if i == 0 || findings[i-1].Pid != f.Pid {
+			fmt.Printf("┬%s", procs[f.Pid].String())
+		}
+		edge := "├"
+		if i == len(findings)-1 || findings[i+1].Pid != f.Pid {
+			edge = "└"
+		}
+		fmt.Printf("%s─ %s %s: %s\n", edge, f.Severity, f.Check, f.Detail)
+		if _, exists := rationale[f.Check]; !exists {
+			rationale[f.Check] = f.Rationale
+			checks = append(checks, f.Check)
+		}
+	}
+	fmt.Println()
+	for _, c := range checks {
+		fmt.Printf("%s: %s\n", c, rationale[c])
+	}
+}
+
+func AuditProcs() *cobra.Command {
+	var checks, skip []string
+	var severity, filter string
+	var list bool
+
+	procsCmd := &cobra.Command{
+		Use:   "procs",
+		Short: "Find suspicious processes",
+		Long: `Run a set of heuristics over every process and report the processes that match,
+each with a severity and the reason it is suspicious. Use --list to see the checks.
+The --filter flag takes the same expressions as 'seer proc list --filter'.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if list {
+				for _, h := range audit.Heuristics {
+					fmt.Printf("%s (%s): %s\n", h.Name, h.Severity, h.Rationale)
+				}
+				return
+			}
+			min, err := audit.ParseSeverity(severity)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			for _, c := range append(slices.Clone(checks), skip...) {
+				if !slices.Contains(audit.HeuristicNames(), c) {
+					fmt.Printf("Unknown check '%s' (expected one of %s).\n\n", c, strings.Join(audit.HeuristicNames(), ", "))
+					cmd.Help()
+					return
+				}
+			}
+			heuristics := []audit.Heuristic{}
+			for _, h := range audit.Heuristics {
+				if (len(checks) == 0 || slices.Contains(checks, h.Name)) && !slices.Contains(skip, h.Name) {
+					heuristics = append(heuristics, h)
+				}
+			}
+
+			all := proc.GetProcesses()
+			procs := all
+			if filter != "" {
+				f, err := proc.ParseFilter(filter)
+				if err != nil {
+					fmt.Printf("%s\n\n", err.Error())
+					cmd.Help()
+					return
+				}
+				procs = proc.FilterProcesses(all, f)
+			}
+			// Checks can look at other processes, ex. the session leader, so they get every process
+			findings := make([]audit.Finding, 0)
+			for _, f := range audit.CheckProcesses(all, heuristics, min) {
+				if _, exists := procs[f.Pid]; exists {
+					findings = append(findings, f)
+				}
+			}
+			if output.Get() == output.Text {
+				printFindings(findings, procs)
+				return
+			}
+			output.Print(findings, nil)
+		},
+	}
+
+	procsCmd.Flags().StringSliceVarP(&checks, "check", "c", nil, "only run these checks")
+	procsCmd.Flags().StringSliceVar(&skip, "skip", nil, "don't run these checks")
+	procsCmd.Flags().StringVarP(&severity, "severity", "s", "low", "only report findings of at least this severity (low, medium, high)")
+	procsCmd.Flags().StringVar(&filter, "filter", "", "only report processes matching a filter expression")
+	procsCmd.Flags().BoolVarP(&list, "list", "l", false, "list the checks and exit")
+
+	return procsCmd
+}
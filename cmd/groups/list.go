@@ -2,6 +2,7 @@ package groups
 
 import (
 	"fmt"
+	"seer/pkg/output"
 	"seer/pkg/users"
 	"sort"
 
@@ -23,9 +24,7 @@ func GroupList() *cobra.Command {
 				groups = append(groups, g)
 			}
 			sort.Slice(groups, func(i, j int) bool { return groups[i].Id < groups[j].Id })
-			for _, g := range groups {
-				fmt.Print(g.Describe())
-			}
+			output.Print(groups, users.Group.Describe)
 		},
 	}
 
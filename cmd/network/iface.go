@@ -0,0 +1,53 @@
+package network
+
+import (
+	"fmt"
+	"seer/pkg/network"
+	"seer/pkg/output"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+func NetworkIface() *cobra.Command {
+	var describe, promisc bool
+
+	iface := &cobra.Command{
+		Use:     "iface [name ...]",
+		Aliases: []string{"ifaces", "if"},
+		Short:   "List network interfaces",
+		Long: `List network interfaces with their addresses and traffic counters.
+Interfaces in promiscuous mode receive traffic meant for other hosts, which is
+how sniffers capture a network. tcpdump and similar tools enable it through packet
+sockets, see seer socks list --type packet for the processes holding them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ifaces, err := network.GetInterfaces()
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			selected := make([]network.Interface, 0)
+			for _, i := range ifaces {
+				if len(args) > 0 && !slices.Contains(args, i.Name) {
+					continue
+				}
+				if promisc && !i.Promiscuous {
+					continue
+				}
+				selected = append(selected, i)
+			}
+
+			text := network.Interface.String
+			if describe {
+				text = network.Interface.Describe
+			}
+			output.Print(selected, text)
+		},
+	}
+
+	iface.Flags().BoolVarP(&describe, "describe", "d", false, "describe each interface in detail")
+	iface.Flags().BoolVarP(&promisc, "promisc", "p", false, "only include promiscuous interfaces")
+
+	return iface
+}
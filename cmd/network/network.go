@@ -0,0 +1,16 @@
+package network
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Network() *cobra.Command {
+	network := &cobra.Command{
+		Use:   "net",
+		Short: "Query network interfaces",
+	}
+
+	network.AddCommand(NetworkIface())
+
+	return network
+}
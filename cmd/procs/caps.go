@@ -0,0 +1,104 @@
+package procs
+
+import (
+	"fmt"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// The capabilities of a process for the caps view
+type capEntry struct {
+	Pid       int               `json:"pid"`
+	Comm      string            `json:"comm"`
+	User      string            `json:"user"`
+	Euid      int               `json:"euid"`
+	Effective proc.Capabilities `json:"effective"`
+	Permitted proc.Capabilities `json:"permitted"`
+	Ambient   proc.Capabilities `json:"ambient"`
+	Dangerous []string          `json:"dangerous"` // Dangerous effective capabilities held by a non-root process
+
+	process proc.Process
+}
+
+func newCapEntry(p proc.Process) capEntry {
+	e := capEntry{
+		Pid:       p.Pid,
+		Comm:      p.Comm,
+		User:      p.User.Username,
+		Euid:      p.Euid,
+		Effective: p.CapEff,
+		Permitted: p.CapPrm,
+		Ambient:   p.CapAmb,
+		Dangerous: []string{},
+		process:   p,
+	}
+	if p.Euid != 0 {
+		e.Dangerous = p.CapEff.Dangerous()
+	}
+	return e
+}
+
+func (e capEntry) String() string {
+	out := fmt.Sprintf("┬%s", e.process.String())
+	edge := "└"
+	if len(e.Dangerous) > 0 {
+		edge = "├"
+	}
+	out += fmt.Sprintf("%s─ effective: %s permitted: %s ambient: %s\n", edge, e.Effective, e.Permitted, e.Ambient)
+	if len(e.Dangerous) > 0 {
+		out += fmt.Sprintf("└─ WARNING: non-root process with %s\n", strings.Join(e.Dangerous, ","))
+	}
+	return out
+}
+
+func (e capEntry) Columns() []string {
+	return []string{"PID", "COMM", "USER", "EUID", "EFFECTIVE", "DANGEROUS"}
+}
+
+func (e capEntry) Row() []string {
+	return []string{strconv.Itoa(e.Pid), e.Comm, e.User, strconv.Itoa(e.Euid), e.Effective.String(), strings.Join(e.Dangerous, ",")}
+}
+
+func ProcsCaps() *cobra.Command {
+	var all, dangerous bool
+	var filter string
+
+	caps := &cobra.Command{
+		Use:   "caps",
+		Short: "Show processes running with capabilities",
+		Long: `Show the capabilities of non-root processes that have any effective capabilities.
+Non-root processes with dangerous effective capabilities such as CAP_SYS_ADMIN,
+CAP_SYS_PTRACE or CAP_NET_RAW are flagged with a warning.` + "\n" + filterHelp(),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			procs, err := applyFilter(proc.GetProcesses(), filter)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			entries := make([]capEntry, 0)
+			for _, p := range sortedProcs(procs) {
+				if p.CapEff == 0 || (p.Euid == 0 && !all) {
+					continue
+				}
+				e := newCapEntry(p)
+				if dangerous && len(e.Dangerous) == 0 {
+					continue
+				}
+				entries = append(entries, e)
+			}
+			output.Print(entries, capEntry.String)
+		},
+	}
+
+	caps.Flags().BoolVarP(&all, "all", "a", false, "include processes running as root")
+	caps.Flags().BoolVarP(&dangerous, "dangerous", "d", false, "only show processes with dangerous capabilities")
+	caps.Flags().StringVar(&filter, "filter", "", filterUsage)
+
+	return caps
+}
@@ -2,30 +2,31 @@ package procs
 
 import (
 	"fmt"
+	"seer/pkg/output"
 	"seer/pkg/proc"
-	"sort"
 	"strconv"
 
 	"github.com/spf13/cobra"
 )
 
 func ProcsDescribe() *cobra.Command {
+	var filter string
+
 	describe := &cobra.Command{
 		Use:   "describe [pid ...]",
 		Short: "Describe processes",
-		Long:  `Describe information about a process or processes.`,
+		Long:  "Describe information about a process or processes.\n" + filterHelp(),
 		Run: func(cmd *cobra.Command, args []string) {
-			procs := proc.GetProcesses()
+			procs, err := applyFilter(proc.GetProcesses(), filter)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
 			if len(args) == 0 {
-				pids := []int{}
-				for pid := range procs {
-					pids = append(pids, pid)
-				}
-				sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
-				for _, pid := range pids {
-					fmt.Print(procs[pid].Describe())
-				}
+				output.Print(sortedProcs(procs), proc.Process.Describe)
 			} else {
+				matches := []proc.Process{}
 				for _, a := range args {
 					pid, err := strconv.Atoi(a)
 					if err != nil {
@@ -41,14 +42,17 @@ func ProcsDescribe() *cobra.Command {
 						}
 					}
 					if p != -1 {
-						fmt.Print(procs[p].Describe())
-					} else {
+						matches = append(matches, procs[p])
+					} else if filter == "" {
 						fmt.Printf("Warning: the process '%d' does not exist\n", pid)
 					}
 				}
+				output.Print(matches, proc.Process.Describe)
 			}
 		},
 	}
 
+	describe.Flags().StringVar(&filter, "filter", "", filterUsage)
+
 	return describe
 }
@@ -0,0 +1,41 @@
+package procs
+
+import (
+	"fmt"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func ProcsEnv() *cobra.Command {
+	var redact bool
+
+	env := &cobra.Command{
+		Use:   "env <pid>",
+		Short: "Show the environment of a process",
+		Long: `Show the environment a process was started with.
+With --redact the values of variables whose names suggest a secret
+(ex. DB_PASSWORD or API_TOKEN) are hidden.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pid, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Printf("Unable to convert '%s' to int.\n\n", args[0])
+				cmd.Help()
+				return
+			}
+			vars, err := proc.Process{Pid: pid}.Environ(redact)
+			if err != nil {
+				fmt.Printf("Failed to read the environment of process '%d': %s\n", pid, err.Error())
+				return
+			}
+			output.Print(vars, proc.EnvVar.String)
+		},
+	}
+
+	env.Flags().BoolVarP(&redact, "redact", "r", false, "hide the values of variables that look like secrets")
+
+	return env
+}
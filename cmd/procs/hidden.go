@@ -0,0 +1,41 @@
+package procs
+
+import (
+	"fmt"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+
+	"github.com/spf13/cobra"
+)
+
+func ProcsHidden() *cobra.Command {
+	var maxPid int
+
+	hidden := &cobra.Command{
+		Use:   "hidden",
+		Short: "Look for processes hidden from the /proc listing",
+		Long: `Look for processes hidden from the /proc directory listing, as done by
+rootkits hooking readdir. Every possible pid is checked with stat and compared
+with the listing, the thread groups and children in /proc/<pid>/task, the
+parents of known processes and the holders of sockets.
+
+Listening or connected tcp and udp sockets that no process holds are reported as well.
+Some kernel services such as nfsd hold sockets without a process.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if maxPid <= 0 {
+				maxPid = proc.PidMax()
+			}
+			found := proc.FindHidden(maxPid)
+			if len(found) == 0 && output.Get() == output.Text {
+				fmt.Printf("No hidden processes found.\n")
+				return
+			}
+			output.Print(found, proc.HiddenProcess.String)
+		},
+	}
+
+	hidden.Flags().IntVar(&maxPid, "max-pid", 0, "largest pid to check (default pid_max)")
+
+	return hidden
+}
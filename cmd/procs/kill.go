@@ -0,0 +1,209 @@
+package procs
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"seer/pkg/proc"
+	"seer/pkg/utils"
+	"slices"
+	"sort"
+	"strconv"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// Get the processes whose comm, cmdline or exe match any of the patterns
+// With inverse the processes matching none of the patterns are returned
+func matchProcs(patterns []string, inverse bool, procs map[int]proc.Process) map[int]proc.Process {
+	regexes := make([]*regexp.Regexp, 0)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			slog.Warn("Pattern failed to complie. Skipping", "pattern", p)
+			continue
+		}
+		regexes = append(regexes, re)
+	}
+	matches := make(map[int]proc.Process)
+	for pid, p := range procs {
+		res := false
+		for _, re := range regexes {
+			if re.MatchString(p.Comm) || re.MatchString(p.Args()) || re.MatchString(p.Exelink) {
+				res = true
+				break
+			}
+		}
+		if res != inverse {
+			matches[pid] = p
+		}
+	}
+	return matches
+}
+
+// True for processes a pattern must never select: init, kernel threads (which
+// have neither an exe nor a cmdline) and the ancestors of seer, ex. its shell
+func protected(p proc.Process, ancestors map[int]bool) bool {
+	return p.Pid == 1 || (p.Exelink == "" && p.Cmdline == "") || ancestors[p.Pid]
+}
+
+// Order targets so parents come before their children
+// Signalling parents first stops them from respawning children that were already signalled
+func killOrder(targets map[int]proc.Process, procs map[int]proc.Process) []proc.Process {
+	depth := func(p proc.Process) int {
+		return len(p.GetParents(procs))
+	}
+	ordered := make([]proc.Process, 0, len(targets))
+	for _, p := range targets {
+		ordered = append(ordered, p)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		di, dj := depth(ordered[i]), depth(ordered[j])
+		if di != dj {
+			return di < dj
+		}
+		return ordered[i].Pid < ordered[j].Pid
+	})
+	return ordered
+}
+
+func ProcsKill() *cobra.Command {
+	var signal_name string
+	var ports []int
+	var tree, stop, yes, dry_run bool
+	var regex, iregex bool
+
+	kill := &cobra.Command{
+		Use:   "kill [pid | pattern ...]",
+		Short: "Send a signal to processes on the system",
+		Long: `Send a signal to processes selected by pid, by regex on comm, cmdline or exe
+(with --regex or --iregex) or by a local port of a socket they hold (with --port).
+
+With --tree the descendants of every selected process are included. Processes
+selected by a pattern never include init, kernel threads or the ancestors of
+seer.
+Parents are signalled before their children. With --stop every target is sent
+SIGSTOP before the signal so that no process can respawn another in between,
+and stopped processes are resumed afterwards unless the signal was KILL.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 && len(ports) == 0 {
+				fmt.Printf("No processes selected.\n\n")
+				cmd.Help()
+				return
+			}
+			sig, err := proc.ParseSignal(signal_name)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+
+			procs := proc.GetProcesses()
+			targets := make(map[int]proc.Process)
+			if regex || iregex {
+				for pid, p := range matchProcs(args, iregex, procs) {
+					targets[pid] = p
+				}
+			} else {
+				for _, a := range args {
+					pid, err := strconv.Atoi(a)
+					if err != nil {
+						fmt.Printf("Invalid pid '%s'.\n\n", a)
+						cmd.Help()
+						return
+					}
+					p, exists := procs[pid]
+					if !exists {
+						slog.Warn("Process does not exist", "pid", pid)
+						continue
+					}
+					targets[pid] = p
+				}
+			}
+			for _, p := range procs {
+				for _, s := range p.Sockets {
+					if slices.Contains(ports, s.Local_port) && (s.Family == "inet" || s.Family == "inet6") {
+						targets[p.Pid] = p
+					}
+				}
+			}
+			if tree {
+				for pid := range targets {
+					for c, p := range subtree(pid, procs) {
+						targets[c] = p
+					}
+				}
+			}
+			// Never signal ourselves, a regex could easily match seer's own cmdline
+			delete(targets, os.Getpid())
+			if regex || iregex {
+				// An inverse pattern selects nearly everything, so patterns never
+				// select init, kernel threads or the shell seer was started from
+				ancestors := make(map[int]bool)
+				for _, p := range procs[os.Getpid()].GetParents(procs) {
+					ancestors[p.Pid] = true
+				}
+				for pid, p := range targets {
+					if protected(p, ancestors) {
+						delete(targets, pid)
+					}
+				}
+			}
+
+			if len(targets) == 0 {
+				fmt.Printf("No matching processes found.\n")
+				return
+			}
+			ordered := killOrder(targets, procs)
+			fmt.Printf("The following %d process(es) will be sent %s:\n", len(ordered), proc.SignalName(sig))
+			for _, p := range ordered {
+				fmt.Printf("  %d %s (%s) %s\n", p.Pid, p.Comm, p.Args(), p.User.Username)
+			}
+			if dry_run {
+				fmt.Printf("Dry run, no signals sent.\n")
+				return
+			}
+			if !yes && !utils.Confirm() {
+				fmt.Printf("Canceled.\n")
+				return
+			}
+
+			if stop {
+				for _, p := range ordered {
+					if err := p.Signal(syscall.SIGSTOP); err != nil {
+						slog.Warn("Failed to stop process", "pid", p.Pid, "error", err.Error())
+					}
+				}
+			}
+			signalled := 0
+			for _, p := range ordered {
+				if err := p.Signal(sig); err != nil {
+					slog.Error("Failed to signal process", "pid", p.Pid, "error", err.Error())
+				} else {
+					signalled += 1
+				}
+			}
+			if stop && sig != syscall.SIGKILL && sig != syscall.SIGSTOP {
+				// Stopped processes can't handle the signal until they are resumed
+				for _, p := range ordered {
+					p.Signal(syscall.SIGCONT)
+				}
+			}
+			fmt.Printf("Signalled %d process(es).\n", signalled)
+		},
+	}
+
+	kill.Flags().StringVarP(&signal_name, "signal", "s", "TERM", "signal to send as a name or number")
+	kill.Flags().IntSliceVarP(&ports, "port", "p", nil, "select processes holding a socket with this local port")
+	kill.Flags().BoolVarP(&tree, "tree", "t", false, "include the descendants of selected processes")
+	kill.Flags().BoolVar(&stop, "stop", false, "stop all targets before signalling them")
+	kill.Flags().BoolVarP(&dry_run, "dry-run", "n", false, "only show the processes that would be signalled")
+	kill.Flags().BoolVarP(&yes, "yes", "y", false, "respond to prompts with yes")
+	kill.Flags().BoolVarP(&regex, "regex", "r", false, "use regex matching")
+	kill.Flags().BoolVarP(&iregex, "iregex", "i", false, "use inverse regex matching")
+	kill.MarkFlagsMutuallyExclusive("regex", "iregex")
+
+	return kill
+}
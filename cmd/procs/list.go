@@ -2,49 +2,137 @@ package procs
 
 import (
 	"fmt"
+	"seer/pkg/output"
 	"seer/pkg/proc"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-// Print process info with procs grouped by executable
-func groupByExe(procs map[int]proc.Process) {
-	exes := make(map[string][]proc.Process)
+// A single file descriptor, used for structured output of `proc ls --fd`
+type fdEntry struct {
+	Pid    int    `json:"pid"`
+	Fd     int    `json:"fd"`
+	Target string `json:"target"`
+}
+
+func (f fdEntry) Columns() []string {
+	return []string{"PID", "FD", "TARGET"}
+}
+
+func (f fdEntry) Row() []string {
+	return []string{strconv.Itoa(f.Pid), strconv.Itoa(f.Fd), f.Target}
+}
+
+// Get processes sorted by pid
+func sortedProcs(procs map[int]proc.Process) []proc.Process {
+	sorted := make([]proc.Process, 0, len(procs))
 	for _, p := range procs {
-		exe := p.Exelink
-		// If exelink is empty fall back to comm (kernel threads)
-		if exe == "" {
-			exe = fmt.Sprintf("(%s)", p.Comm)
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Pid < sorted[j].Pid })
+	return sorted
+}
+
+// Layouts accepted by --started-after and --started-before, in local time unless a zone is given
+var timeLayouts = []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", time.DateOnly, time.TimeOnly, "15:04"}
+
+// Parse a time given on the command line
+// Durations such as 10m are taken as that long ago and times without a date as today
+func parseTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == time.TimeOnly || layout == "15:04" {
+			now := time.Now()
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
 		}
-		exes[exe] = append(exes[exe], p)
+		return t, nil
 	}
-	for e := range exes {
-		fmt.Printf("┌<%s> (Count: %d)\n", e, len(exes[e]))
-		for i, p := range exes[e] {
-			line := '├'
-			if i == len(exes[e])-1 {
-				line = '└'
-			}
-			fmt.Printf("%c[%d]->[%d] %s started %d seconds ago by %s\n", line, p.Ppid, p.Pid, p.Cmdline, p.Age(), p.User.Username)
+	return time.Time{}, fmt.Errorf("invalid time '%s' (expected ex. 2024-05-01 13:30, 13:30 or 10m)", value)
+}
+
+// Get the processes started within a window, a zero after or before leaves that side open
+// Processes with an unknown start time are excluded
+func startedBetween(procs map[int]proc.Process, after time.Time, before time.Time) map[int]proc.Process {
+	matches := make(map[int]proc.Process)
+	for pid, p := range procs {
+		if p.Started.IsZero() {
+			continue
+		}
+		if (!after.IsZero() && p.Started.Before(after)) || (!before.IsZero() && p.Started.After(before)) {
+			continue
 		}
+		matches[pid] = p
 	}
+	return matches
 }
 
-func groupByUser(procs map[int]proc.Process) {
-	userProcs := make(map[string][]proc.Process)
-	for _, p := range procs {
-		user := p.User.Username
-		userProcs[user] = append(userProcs[user], p)
+// Print grouped processes in a non text output format
+func printGroups(groups map[string][]proc.Process) {
+	if output.Structured() {
+		output.Encode(groups)
+		return
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("<%s> (Count: %d)\n", k, len(groups[k]))
+		output.PrintTable(groups[k])
+	}
+}
+
+// The group of a process when grouping by executable
+func exeGroup(p proc.Process) string {
+	// If exelink is empty fall back to comm (kernel threads)
+	if p.Exelink == "" {
+		return fmt.Sprintf("(%s)", p.Comm)
+	}
+	return p.Exelink
+}
+
+// The group of a process when grouping by user
+func userGroup(p proc.Process) string {
+	return p.User.Username
+}
+
+// The group of a process when grouping by container, processes outside of containers are grouped under host
+func containerGroup(p proc.Process) string {
+	if p.Container == nil {
+		return "host"
 	}
-	for u := range userProcs {
-		fmt.Printf("┌<%s> (Count: %d)\n", u, len(userProcs[u]))
-		for i, p := range userProcs[u] {
+	return p.Container.String()
+}
+
+// Print process info with procs grouped by the key of each process
+func groupBy(procs map[int]proc.Process, key func(proc.Process) string) {
+	groups := make(map[string][]proc.Process)
+	for _, p := range sortedProcs(procs) {
+		k := key(p)
+		groups[k] = append(groups[k], p)
+	}
+	if output.Get() != output.Text {
+		printGroups(groups)
+		return
+	}
+	for g := range groups {
+		fmt.Printf("┌<%s> (Count: %d)\n", g, len(groups[g]))
+		for i, p := range groups[g] {
 			line := '├'
-			if i == len(userProcs[u])-1 {
+			if i == len(groups[g])-1 {
 				line = '└'
 			}
-			fmt.Printf("%c[%d]->[%d] %s started %d seconds ago by %s\n", line, p.Ppid, p.Pid, p.Cmdline, p.Age(), p.User.Username)
+			fmt.Printf("%c[%d]->[%d] %s started %s (%d seconds ago) by %s\n", line, p.Ppid, p.Pid, p.Cmdline, proc.FormatStarted(p.Started), p.Age(), p.User.Username)
 		}
 	}
 }
@@ -52,15 +140,56 @@ func groupByUser(procs map[int]proc.Process) {
 func ProcsList() *cobra.Command {
 	var byExe bool
 	var byUser bool
+	var byContainer bool
 	var lsFds bool
 	var lsSockets bool
+	var numeric, resolve bool
+	var filter string
+	var startedAfter, startedBefore string
 
 	list := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List running processes",
+		Long: `List running processes.
+--started-after and --started-before select processes started within a window, ex.
+  seer proc list --started-after "2024-05-01 13:00" --started-before "2024-05-01 14:30"
+Times are local unless given in RFC 3339 form, times without a date are today and
+durations such as 30m mean that long ago.
+With --socket ports and addresses are named from /etc/services and /etc/hosts unless
+--numeric is given, --resolve also looks up other addresses with reverse DNS.
+` + filterHelp(),
 		Run: func(cmd *cobra.Command, args []string) {
-			procs := proc.GetProcesses()
+			procs, err := applyFilter(proc.GetProcesses(), filter)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			if startedAfter != "" || startedBefore != "" {
+				var after, before time.Time
+				if startedAfter != "" {
+					if after, err = parseTime(startedAfter); err != nil {
+						fmt.Printf("%s\n\n", err.Error())
+						cmd.Help()
+						return
+					}
+				}
+				if startedBefore != "" {
+					if before, err = parseTime(startedBefore); err != nil {
+						fmt.Printf("%s\n\n", err.Error())
+						cmd.Help()
+						return
+					}
+				}
+				procs = startedBetween(procs, after, before)
+			}
+			if lsSockets && output.Get() == output.Text {
+				proc.SetResolver(proc.Resolver{Numeric: numeric, DNS: resolve})
+				for _, p := range procs {
+					proc.PrefetchNames(p.Sockets)
+				}
+			}
 			pids := []int{}
 			for pid := range procs {
 				pids = append(pids, pid)
@@ -68,9 +197,32 @@ func ProcsList() *cobra.Command {
 			sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
 
 			if byExe {
-				groupByExe(procs)
+				groupBy(procs, exeGroup)
 			} else if byUser {
-				groupByUser(procs)
+				groupBy(procs, userGroup)
+			} else if byContainer {
+				groupBy(procs, containerGroup)
+			} else if output.Get() != output.Text && lsFds {
+				entries := []fdEntry{}
+				for _, pid := range pids {
+					fds, e := procs[pid].GetFds()
+					if e != nil {
+						fmt.Printf("Failed to get file descriptors: %s\n", e.Error())
+						return
+					}
+					ids := []int{}
+					for id := range fds {
+						ids = append(ids, id)
+					}
+					sort.Ints(ids)
+					for _, id := range ids {
+						entries = append(entries, fdEntry{Pid: pid, Fd: id, Target: fds[id]})
+					}
+				}
+				output.Print(entries, nil)
+			} else if output.Get() != output.Text {
+				// Sockets are included in the structured process records
+				output.Print(sortedProcs(procs), proc.Process.String)
 			} else {
 				i := 0
 				for _, pid := range pids {
@@ -138,9 +290,16 @@ func ProcsList() *cobra.Command {
 
 	list.Flags().BoolVarP(&byExe, "exe", "e", false, "group processes by executable")
 	list.Flags().BoolVarP(&byUser, "user", "u", false, "group processes by user")
+	list.Flags().BoolVarP(&byContainer, "by-container", "c", false, "group processes by container")
 	list.Flags().BoolVarP(&lsFds, "fd", "f", false, "list the file descriptors related to each process")
 	list.Flags().BoolVarP(&lsSockets, "socket", "s", false, "list the sockets related to each process")
-	list.MarkFlagsMutuallyExclusive("exe", "fd", "socket")
+	list.Flags().BoolVarP(&numeric, "numeric", "n", false, "don't resolve service and host names of sockets")
+	list.Flags().BoolVarP(&resolve, "resolve", "r", false, "resolve socket addresses without an /etc/hosts entry with reverse DNS")
+	list.Flags().StringVar(&filter, "filter", "", filterUsage)
+	list.Flags().StringVar(&startedAfter, "started-after", "", "only include processes started at or after this time")
+	list.Flags().StringVar(&startedBefore, "started-before", "", "only include processes started at or before this time")
+	list.MarkFlagsMutuallyExclusive("exe", "user", "by-container", "fd", "socket")
+	list.MarkFlagsMutuallyExclusive("numeric", "resolve")
 
 	return list
 }
@@ -0,0 +1,120 @@
+package procs
+
+import (
+	"fmt"
+	"log/slog"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// A mapping along with the process it belongs to, used when scanning every process
+type mapEntry struct {
+	Pid     int          `json:"pid"`
+	Comm    string       `json:"comm"`
+	Mapping proc.Mapping `json:"mapping"`
+}
+
+func (e mapEntry) Columns() []string {
+	return append([]string{"PID", "COMM"}, e.Mapping.Columns()...)
+}
+
+func (e mapEntry) Row() []string {
+	return append([]string{strconv.Itoa(e.Pid), e.Comm}, e.Mapping.Row()...)
+}
+
+// Keep only the mappings with warnings
+func suspiciousMaps(maps []proc.Mapping) []proc.Mapping {
+	found := make([]proc.Mapping, 0)
+	for _, m := range maps {
+		if len(m.Warnings) > 0 {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// Print the suspicious mappings of every process
+func scanMaps(procs map[int]proc.Process) {
+	entries := make([]mapEntry, 0)
+	for _, p := range sortedProcs(procs) {
+		maps, err := p.Maps()
+		if err != nil {
+			slog.Debug("Failed to read process maps", "process", p.Pid, "error", err.Error())
+			continue
+		}
+		found := suspiciousMaps(maps)
+		for _, m := range found {
+			entries = append(entries, mapEntry{Pid: p.Pid, Comm: p.Comm, Mapping: m})
+		}
+		if output.Get() != output.Text || len(found) == 0 {
+			continue
+		}
+		fmt.Printf("┬%s", p.String())
+		for i, m := range found {
+			edge := "├"
+			if i == len(found)-1 {
+				edge = "└"
+			}
+			fmt.Printf("%s─%s", edge, m.String())
+		}
+	}
+	if output.Get() != output.Text {
+		output.Print(entries, nil)
+	}
+}
+
+func ProcsMaps() *cobra.Command {
+	var suspicious bool
+	var filter string
+
+	maps := &cobra.Command{
+		Use:   "maps [pid]",
+		Short: "Show the memory mappings of a process",
+		Long: `Show the memory mappings of a process.
+
+Mappings that look like injected code are flagged with a warning:
+  anonymous rwx memory  writable and executable memory not backed by a file
+  executable memfd      code loaded from a memfd_create file that never touched the disk
+  deleted file          a file that was removed from disk after being mapped
+  loaded from <dir>     code or a library loaded from /tmp, /var/tmp, /dev/shm, /home, /root or /run/user
+
+Just in time compilers (java, node, browsers) legitimately use anonymous rwx memory.
+Without a pid the suspicious mappings of every process are shown.` + "\n" + filterHelp(),
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				procs, err := applyFilter(proc.GetProcesses(), filter)
+				if err != nil {
+					fmt.Printf("%s\n\n", err.Error())
+					cmd.Help()
+					return
+				}
+				scanMaps(procs)
+				return
+			}
+			pid, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Printf("Unable to convert '%s' to int.\n\n", args[0])
+				cmd.Help()
+				return
+			}
+			found, err := proc.Process{Pid: pid}.Maps()
+			if err != nil {
+				fmt.Printf("Failed to read the maps of process '%d': %s\n", pid, err.Error())
+				return
+			}
+			if suspicious {
+				found = suspiciousMaps(found)
+			}
+			output.Print(found, proc.Mapping.String)
+		},
+	}
+
+	maps.Flags().BoolVarP(&suspicious, "suspicious", "s", false, "only show mappings with warnings, always set without a pid")
+	maps.Flags().StringVar(&filter, "filter", "", filterUsage)
+
+	return maps
+}
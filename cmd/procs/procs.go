@@ -1,9 +1,42 @@
 package procs
 
 import (
+	"seer/pkg/proc"
+
 	"github.com/spf13/cobra"
 )
 
+const filterUsage = "only include processes matching a filter expression"
+
+// Help text describing the filter language
+func filterHelp() string {
+	help := `
+Filters are expressions over process fields, for example:
+  uid >= 1000 and exedel and socket.state == LISTEN
+  comm =~ "^(nc|ncat|socat)$" or (user != root && age < 10m)
+
+Comparisons: == (or =), !=, <, <=, >, >=, =~ (regex match), !~ (regex non-match)
+Boolean operators: and (&&), or (||), not (!) and parentheses
+Values containing spaces, parentheses or operators must be quoted.
+Comparisons on socket fields match if any socket of the process matches.
+
+Fields:
+`
+	return help + proc.FilterHelp()
+}
+
+// Keep the processes matching the filter expression, all processes if it is empty
+func applyFilter(procs map[int]proc.Process, expr string) (map[int]proc.Process, error) {
+	if expr == "" {
+		return procs, nil
+	}
+	f, err := proc.ParseFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	return proc.FilterProcesses(procs, f), nil
+}
+
 func Procs() *cobra.Command {
 	procs := &cobra.Command{
 		Use:     "proc",
@@ -14,6 +47,14 @@ func Procs() *cobra.Command {
 	procs.AddCommand(ProcsList())
 	procs.AddCommand(ProcsDescribe())
 	procs.AddCommand(ProcsTree())
+	procs.AddCommand(ProcsKill())
+	procs.AddCommand(ProcsCaps())
+	procs.AddCommand(ProcsMaps())
+	procs.AddCommand(ProcsEnv())
+	procs.AddCommand(ProcsHidden())
+	procs.AddCommand(ProcsVerify())
+	procs.AddCommand(ProcsThreads())
+	procs.AddCommand(ProcsTop())
 
 	return procs
 }
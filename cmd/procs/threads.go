@@ -0,0 +1,61 @@
+package procs
+
+import (
+	"fmt"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func ProcsThreads() *cobra.Command {
+	var renamed, by_cpu bool
+
+	threads := &cobra.Command{
+		Use:   "threads <pid>",
+		Short: "List the threads of a process",
+		Long: `List the threads of a process with their cpu usage.
+Threads named differently from the thread group leader are marked as renamed.
+Threads named like kernel threads or running with different user ids than the
+process are flagged with a warning.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pid, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Printf("Unable to convert '%s' to int.\n\n", args[0])
+				cmd.Help()
+				return
+			}
+			p, exists := proc.GetProcesses()[pid]
+			if !exists {
+				fmt.Printf("The process '%d' does not exist.\n", pid)
+				return
+			}
+			list, err := p.GetThreads()
+			if err != nil {
+				fmt.Printf("Failed to read the threads of process '%d': %s\n", pid, err.Error())
+				return
+			}
+			matches := make([]proc.Thread, 0)
+			for _, t := range list {
+				if renamed && !t.Renamed {
+					continue
+				}
+				matches = append(matches, t)
+			}
+			if by_cpu {
+				sort.SliceStable(matches, func(i, j int) bool {
+					return matches[i].CPUPercent() > matches[j].CPUPercent()
+				})
+			}
+			output.Print(matches, proc.Thread.String)
+		},
+	}
+
+	threads.Flags().BoolVarP(&renamed, "renamed", "r", false, "only show threads named differently from the process")
+	threads.Flags().BoolVarP(&by_cpu, "cpu", "c", false, "sort threads by cpu usage")
+
+	return threads
+}
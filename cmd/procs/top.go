@@ -0,0 +1,218 @@
+package procs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"seer/pkg/utils"
+	"slices"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Orders available for `proc top --sort`
+var topSorts = []string{"cpu", "mem", "io", "age"}
+
+// The combined usage of a group of processes
+type usageTotal struct {
+	Group      string  `json:"group"`
+	Count      int     `json:"count"`
+	CPU        float64 `json:"cpu_percent"`
+	RSS        uint64  `json:"rss"`
+	PSS        uint64  `json:"pss"`
+	ReadBytes  uint64  `json:"read_bytes"`
+	WriteBytes uint64  `json:"write_bytes"`
+	IORate     float64 `json:"io_rate"`
+	Newest     int     `json:"newest"` // Age of the youngest process in the group
+}
+
+func (t usageTotal) String() string {
+	return fmt.Sprintf("<%s> (Count: %d) cpu: %.1f%% rss: %s pss: %s io: %s/s\n",
+		t.Group, t.Count, t.CPU, utils.FormatBytes(t.RSS), utils.FormatBytes(t.PSS), utils.FormatBytes(uint64(t.IORate)),
+	)
+}
+
+func (t usageTotal) Columns() []string {
+	return []string{"GROUP", "COUNT", "CPU%", "RSS", "PSS", "READ", "WRITE", "IO/S"}
+}
+
+func (t usageTotal) Row() []string {
+	return []string{
+		t.Group,
+		strconv.Itoa(t.Count),
+		fmt.Sprintf("%.1f", t.CPU),
+		utils.FormatBytes(t.RSS),
+		utils.FormatBytes(t.PSS),
+		utils.FormatBytes(t.ReadBytes),
+		utils.FormatBytes(t.WriteBytes),
+		utils.FormatBytes(uint64(t.IORate)),
+	}
+}
+
+// Sort usage in place, the heaviest or youngest processes first
+func sortUsage(usage []proc.Usage, by string) {
+	// Ties are ordered by pid
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Pid < usage[j].Pid })
+	sort.SliceStable(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		switch by {
+		case "mem":
+			return a.RSS > b.RSS
+		case "io":
+			return a.IORate > b.IORate || (a.IORate == b.IORate && a.ReadBytes+a.WriteBytes > b.ReadBytes+b.WriteBytes)
+		case "age":
+			return a.Age < b.Age
+		default:
+			return a.CPU > b.CPU
+		}
+	})
+}
+
+// Sum the usage of processes grouped with group, sorted like sortUsage
+func totalUsage(usage []proc.Usage, group func(proc.Process) string, by string) []usageTotal {
+	totals := make(map[string]*usageTotal)
+	for _, u := range usage {
+		key := group(u.Process())
+		t, exists := totals[key]
+		if !exists {
+			t = &usageTotal{Group: key, Newest: u.Age}
+			totals[key] = t
+		}
+		t.Count += 1
+		t.CPU += u.CPU
+		t.RSS += u.RSS
+		t.PSS += u.PSS
+		t.ReadBytes += u.ReadBytes
+		t.WriteBytes += u.WriteBytes
+		t.IORate += u.IORate
+		t.Newest = min(t.Newest, u.Age)
+	}
+
+	list := make([]usageTotal, 0, len(totals))
+	for _, t := range totals {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Group < list[j].Group })
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch by {
+		case "mem":
+			return a.RSS > b.RSS
+		case "io":
+			return a.IORate > b.IORate || (a.IORate == b.IORate && a.ReadBytes+a.WriteBytes > b.ReadBytes+b.WriteBytes)
+		case "age":
+			return a.Newest < b.Newest
+		default:
+			return a.CPU > b.CPU
+		}
+	})
+	return list
+}
+
+// Print one sample of `proc top`
+// A refreshing text view redraws the screen as a table, structured output
+// is written as one document per sample
+func printTop[T output.Tabular](items []T, text func(T) string, limit int, refresh bool, header string) {
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	if !refresh {
+		output.Print(items, text)
+		return
+	}
+	if output.Structured() {
+		output.Stream(items, "")
+		return
+	}
+	// Clear the screen and move to the top left
+	fmt.Print("\033[H\033[2J")
+	fmt.Println(header)
+	output.PrintTable(items)
+}
+
+func ProcsTop() *cobra.Command {
+	var sort_by, filter string
+	var interval time.Duration
+	var refresh, byExe, byUser bool
+	var limit int
+
+	top := &cobra.Command{
+		Use:   "top",
+		Short: "Show the resource usage of processes",
+		Long: `Show the cpu, memory and io usage of processes.
+Processes are sampled twice, --interval apart, to compute the cpu percentage and
+io rate. Memory is the resident set size from statm and the proportional set size
+from smaps_rollup, which counts shared pages once. The io counters of other users'
+processes can only be read by root.
+
+With --refresh the view is redrawn every interval until interrupted.
+With --user or --exe the usage is summed for each group.` + "\n" + filterHelp(),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !slices.Contains(topSorts, sort_by) {
+				fmt.Printf("Unknown sort '%s' (expected one of %v).\n\n", sort_by, topSorts)
+				cmd.Help()
+				return
+			}
+			if interval <= 0 {
+				fmt.Printf("The interval must be positive.\n\n")
+				cmd.Help()
+				return
+			}
+			procs, err := applyFilter(proc.GetProcesses(), filter)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			sampler := proc.Sampler{}
+			sampler.Sample(procs)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+
+				// Pick up processes started since the last sample
+				procs, _ = applyFilter(proc.GetProcesses(), filter)
+				usage := sampler.Sample(procs)
+				header := fmt.Sprintf("%s  %d processes  sorted by %s", time.Now().Format(time.TimeOnly), len(usage), sort_by)
+				if byExe || byUser {
+					group := userGroup
+					if byExe {
+						group = exeGroup
+					}
+					printTop(totalUsage(usage, group, sort_by), usageTotal.String, limit, refresh, header)
+				} else {
+					sortUsage(usage, sort_by)
+					printTop(usage, proc.Usage.String, limit, refresh, header)
+				}
+				if !refresh {
+					return
+				}
+			}
+		},
+	}
+
+	top.Flags().StringVarP(&sort_by, "sort", "s", "cpu", "sort by cpu, mem, io or age")
+	top.Flags().DurationVarP(&interval, "interval", "i", time.Second, "time between samples")
+	top.Flags().BoolVarP(&refresh, "refresh", "r", false, "redraw the view every interval")
+	top.Flags().IntVarP(&limit, "limit", "n", 0, "only show this many entries, 0 for all")
+	top.Flags().BoolVarP(&byExe, "exe", "e", false, "sum usage by executable")
+	top.Flags().BoolVarP(&byUser, "user", "u", false, "sum usage by user")
+	top.Flags().StringVar(&filter, "filter", "", filterUsage)
+
+	return top
+}
@@ -2,8 +2,10 @@ package procs
 
 import (
 	"fmt"
+	"seer/pkg/output"
 	"seer/pkg/proc"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -35,6 +37,8 @@ func printTree(root_pid int, procs map[int]proc.Process) {
 			}
 		}
 		// Add edges
+		// cont is the prefix for lines below this vertex, ex. its threads
+		cont := ""
 		if !(current.Pid == subtree_root) {
 			parents := current.GetParents(procs)
 			edges := make([]string, 0)
@@ -64,6 +68,13 @@ func printTree(root_pid int, procs map[int]proc.Process) {
 			// Edges are reversed
 			for i := len(edges) - 1; i >= 0; i-- {
 				fmt.Print(edges[i])
+				if i == 0 && edges[i] == "├" {
+					cont += "│"
+				} else if i == 0 {
+					cont += " "
+				} else {
+					cont += edges[i]
+				}
 			}
 		}
 		// Add an edge to connect to any children of this process if needed
@@ -72,7 +83,17 @@ func printTree(root_pid int, procs map[int]proc.Process) {
 		} else {
 			fmt.Print("─")
 		}
-		fmt.Print(current.String())
+		fmt.Print(treeLabel(current))
+		if len(current.Children) > 0 {
+			cont += "│"
+		} else {
+			cont += " "
+		}
+		for _, t := range current.Threads {
+			if t.Tid != current.Pid {
+				fmt.Printf("%s~%s", cont, t.String())
+			}
+		}
 
 		for _, c := range current.Children {
 			dfs(root, current.Pid, procs[c], procs)
@@ -90,10 +111,88 @@ func printTree(root_pid int, procs map[int]proc.Process) {
 	}
 }
 
+// The process line for the tree, marking containers and namespace changes
+func treeLabel(p proc.Process) string {
+	label := strings.TrimSuffix(p.String(), "\n")
+	if p.Container != nil {
+		label += fmt.Sprintf(" <%s>", p.Container)
+	}
+	if len(p.NsChanged) > 0 {
+		label += fmt.Sprintf(" [new %s namespace]", strings.Join(p.NsChanged, ","))
+	}
+	return label + "\n"
+}
+
+// Get the process with pid root and all of its descendants
+// A root of 0 selects every process
+func subtree(root int, procs map[int]proc.Process) map[int]proc.Process {
+	if root == 0 {
+		return procs
+	}
+	tree := make(map[int]proc.Process)
+	var visit func(pid int)
+	visit = func(pid int) {
+		p, exists := procs[pid]
+		if !exists {
+			return
+		}
+		tree[pid] = p
+		for _, c := range p.Children {
+			visit(c)
+		}
+	}
+	visit(root)
+	return tree
+}
+
+// Get the processes matching f along with all of their ancestors
+// Children that don't match are pruned so the tree only shows relevant branches
+func filterTree(procs map[int]proc.Process, f proc.Filter) map[int]proc.Process {
+	keep := make(map[int]bool)
+	for _, p := range procs {
+		if !f.Match(p) {
+			continue
+		}
+		for current, exists := p, true; exists && !keep[current.Pid]; current, exists = procs[current.Ppid] {
+			keep[current.Pid] = true
+		}
+	}
+
+	tree := make(map[int]proc.Process)
+	for pid := range keep {
+		p := procs[pid]
+		children := make([]int, 0)
+		for _, c := range p.Children {
+			if keep[c] {
+				children = append(children, c)
+			}
+		}
+		p.Children = children
+		tree[pid] = p
+	}
+	return tree
+}
+
+// Read the threads of root and its descendants for the tree
+func addThreads(root int, procs map[int]proc.Process) {
+	for pid, p := range subtree(root, procs) {
+		threads, err := p.GetThreads()
+		if err != nil {
+			continue
+		}
+		p.Threads = threads
+		procs[pid] = p
+	}
+}
+
 func ProcsTree() *cobra.Command {
+	var filter string
+	var threads bool
+
 	tree := &cobra.Command{
 		Use:   "tree [pid]",
 		Short: "Display a process tree",
+		Long:  "Display a process tree.\nWhen filtering, matching processes are shown along with their ancestors.\nWith --threads the threads of each process are listed below it, marked with ~.\n" + filterHelp(),
 		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			root := 0
@@ -107,9 +206,37 @@ func ProcsTree() *cobra.Command {
 				}
 			}
 			procs := proc.GetProcesses()
+			if filter != "" {
+				f, err := proc.ParseFilter(filter)
+				if err != nil {
+					fmt.Printf("%s\n\n", err.Error())
+					cmd.Help()
+					return
+				}
+				if _, exists := procs[root]; root != 0 && !exists {
+					fmt.Printf("The process '%d' does not exist.\n", root)
+					return
+				}
+				procs = filterTree(subtree(root, procs), f)
+				if len(procs) == 0 {
+					fmt.Printf("No matching processes.\n")
+					return
+				}
+			}
+			if threads {
+				addThreads(root, procs)
+			}
+			if output.Get() != output.Text {
+				// The tree structure is preserved through the children of each process
+				output.Print(sortedProcs(subtree(root, procs)), proc.Process.String)
+				return
+			}
 			printTree(root, procs)
 		},
 	}
 
+	tree.Flags().StringVar(&filter, "filter", "", filterUsage)
+	tree.Flags().BoolVarP(&threads, "threads", "t", false, "show the threads of each process")
+
 	return tree
 }
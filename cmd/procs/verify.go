@@ -0,0 +1,80 @@
+package procs
+
+import (
+	"fmt"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"seer/pkg/verify"
+
+	"github.com/spf13/cobra"
+)
+
+func ProcsVerify() *cobra.Command {
+	var allow, deny, filter string
+	var packages, unknown bool
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check process executables against known hashes",
+		Long: `Label the executable of every process as known-good, known-bad or unknown
+using lists of hashes. Lists hold one md5, sha1 or sha256 hash per line optionally
+followed by a name, so the output of sha256sum can be used directly. Lines starting
+with # are ignored. A hash in the deny list is known-bad even if it is also allowed.
+
+With --package executables are compared against the checksums recorded by dpkg
+or rpm, which catches replaced system binaries.` + "\n" + filterHelp(),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if allow == "" && deny == "" && !packages {
+				fmt.Printf("At least one of --allow, --deny or --package is required.\n\n")
+				cmd.Help()
+				return
+			}
+			allow_list, deny_list := verify.HashList{}, verify.HashList{}
+			var err error
+			if allow != "" {
+				if allow_list, err = verify.LoadHashList(allow); err != nil {
+					fmt.Printf("%s\n", err.Error())
+					return
+				}
+			}
+			if deny != "" {
+				if deny_list, err = verify.LoadHashList(deny); err != nil {
+					fmt.Printf("%s\n", err.Error())
+					return
+				}
+			}
+			var db *verify.PackageDB
+			if packages {
+				db = verify.LoadPackageDB()
+				if db.Empty() {
+					fmt.Printf("No dpkg or rpm package database found.\n")
+					return
+				}
+			}
+
+			procs, err := applyFilter(proc.GetProcesses(), filter)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			results := make([]verify.Result, 0)
+			for _, r := range verify.Verify(procs, allow_list, deny_list, db) {
+				if unknown && r.Label == verify.KnownGood && r.Package != verify.PackageModified {
+					continue
+				}
+				results = append(results, r)
+			}
+			output.Print(results, verify.Result.String)
+		},
+	}
+
+	verifyCmd.Flags().StringVar(&allow, "allow", "", "file with hashes of known-good executables")
+	verifyCmd.Flags().StringVar(&deny, "deny", "", "file with hashes of known-bad executables")
+	verifyCmd.Flags().BoolVarP(&packages, "package", "p", false, "compare executables against package manager checksums")
+	verifyCmd.Flags().BoolVarP(&unknown, "unknown", "u", false, "hide known-good executables")
+	verifyCmd.Flags().StringVar(&filter, "filter", "", filterUsage)
+
+	return verifyCmd
+}
@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"fmt"
+	"log/slog"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"seer/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+func SnapshotDiff() *cobra.Command {
+	diff := &cobra.Command{
+		Use:   "diff <old> [new | live]",
+		Short: "Compare a snapshot against another snapshot or the live system",
+		Long: `Compare a snapshot against another snapshot or the live system (the default).
+Reports added and removed users and groups, group membership changes,
+shell and password hash changes, new listening sockets and new executables.
+Executables of the live system are hashed with the algorithm the snapshot used,
+regardless of --hash.
+Use --output json or yaml for a machine readable diff.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			old, err := snapshot.Load(args[0])
+			if err != nil {
+				fmt.Printf("%s\n", err.Error())
+				return
+			}
+			var current snapshot.Snapshot
+			if len(args) == 1 || args[1] == "live" {
+				// Executables are only comparable when hashed with the algorithm the snapshot used
+				if alg := old.HashAlgorithm(); alg != "" && alg != proc.HashAlgorithm {
+					if err := proc.SetHashAlgorithm(alg); err != nil {
+						fmt.Printf("%s\n", err.Error())
+						return
+					}
+					slog.Info("Hashing executables with the algorithm of the snapshot", "algorithm", alg)
+				}
+				current = snapshot.Take()
+			} else {
+				current, err = snapshot.Load(args[1])
+				if err != nil {
+					fmt.Printf("%s\n", err.Error())
+					return
+				}
+			}
+
+			changes := snapshot.Compare(old, current)
+			if output.Structured() {
+				output.Encode(changes)
+				return
+			}
+			if len(changes.Changes) == 0 && output.Get() == output.Text {
+				fmt.Printf("No changes.\n")
+				return
+			}
+			output.Print(changes.Changes, snapshot.Change.String)
+		},
+	}
+
+	return diff
+}
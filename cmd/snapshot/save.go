@@ -0,0 +1,27 @@
+package snapshot
+
+import (
+	"fmt"
+	"seer/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+func SnapshotSave() *cobra.Command {
+	save := &cobra.Command{
+		Use:   "save <file>",
+		Short: "Save processes, sockets, users and groups to a file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			snap := snapshot.Take()
+			if err := snap.Save(args[0]); err != nil {
+				fmt.Printf("%s\n", err.Error())
+				return
+			}
+			fmt.Printf("Saved %d process(es), %d socket(s), %d user(s) and %d group(s) to %s\n",
+				len(snap.Processes), len(snap.Sockets), len(snap.Users), len(snap.Groups), args[0])
+		},
+	}
+
+	return save
+}
@@ -0,0 +1,18 @@
+package snapshot
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Snapshot() *cobra.Command {
+	snapshot := &cobra.Command{
+		Use:     "snapshot",
+		Aliases: []string{"snap"},
+		Short:   "Save the state of the system and compare it against later states",
+	}
+
+	snapshot.AddCommand(SnapshotSave())
+	snapshot.AddCommand(SnapshotDiff())
+
+	return snapshot
+}
@@ -2,22 +2,31 @@ package socks
 
 import (
 	"fmt"
+	"seer/pkg/output"
 	"seer/pkg/proc"
 
 	"github.com/spf13/cobra"
 )
 
 func SocketDescribe() *cobra.Command {
+	var flags socketFlags
+
 	describe := &cobra.Command{
 		Use:   "describe",
 		Short: "Describe sockets",
 		Run: func(cmd *cobra.Command, args []string) {
-			sockets := proc.GetSockets()
-			for _, s := range sockets {
-				fmt.Print(s.Describe())
+			sockets, err := flags.apply(proc.GetSockets())
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
 			}
+			annotate(sockets)
+			output.Print(sockets, proc.Socket.Describe)
 		},
 	}
 
+	flags.add(describe)
+
 	return describe
 }
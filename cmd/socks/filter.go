@@ -0,0 +1,110 @@
+package socks
+
+import (
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags shared by the socket commands for selecting and ordering sockets
+type socketFlags struct {
+	types     []string
+	states    []string
+	protos    []string
+	lports    []int
+	rports    []int
+	laddrs    []string
+	raddrs    []string
+	inodes    []int
+	listening bool
+	uids      []int
+	sort      string
+	numeric   bool
+	resolve   bool
+}
+
+func (f *socketFlags) add(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&f.types, "type", "t", nil, "only include sockets of these types (inet, inet6, unix, packet, netlink)")
+	cmd.Flags().StringSliceVar(&f.states, "state", nil, "only include sockets in these states, ex. LISTEN or ESTABLISHED")
+	cmd.Flags().StringSliceVar(&f.protos, "proto", nil, "only include these protocols, ex. tcp (ipv4 and ipv6), tcp6 or udp")
+	cmd.Flags().IntSliceVar(&f.lports, "lport", nil, "only include sockets with these local ports")
+	cmd.Flags().IntSliceVar(&f.rports, "rport", nil, "only include sockets with these remote ports")
+	cmd.Flags().StringSliceVar(&f.laddrs, "laddr", nil, "only include sockets with a local address in these networks, ex. 127.0.0.1")
+	cmd.Flags().StringSliceVar(&f.raddrs, "raddr", nil, "only include sockets with a remote address in these networks, ex. 10.0.0.0/8")
+	cmd.Flags().IntSliceVarP(&f.inodes, "inode", "i", nil, "only include sockets with these inodes")
+	cmd.Flags().BoolVarP(&f.listening, "listening", "l", false, "only include listening sockets, including bound udp and raw sockets")
+	cmd.Flags().IntSliceVar(&f.uids, "uid", nil, "only include sockets owned by these user ids (never unix or netlink sockets)")
+	cmd.Flags().StringVarP(&f.sort, "sort", "s", "", "sort by proto, local, lport, remote, rport, state, uid or inode")
+	cmd.Flags().BoolVarP(&f.numeric, "numeric", "n", false, "don't resolve service and host names")
+	cmd.Flags().BoolVarP(&f.resolve, "resolve", "r", false, "resolve addresses without an /etc/hosts entry with reverse DNS")
+	cmd.MarkFlagsMutuallyExclusive("numeric", "resolve")
+}
+
+// Get the sockets selected by the flags in the requested order
+// Names for the text output are resolved as requested by the flags
+func (f socketFlags) apply(sockets []proc.Socket) ([]proc.Socket, error) {
+	filter := proc.SocketFilter{
+		Protocols:   f.protos,
+		LocalPorts:  f.lports,
+		RemotePorts: f.rports,
+		Inodes:      f.inodes,
+		Listening:   f.listening,
+		Uids:        f.uids,
+	}
+	for _, name := range f.states {
+		state, err := proc.ParseState(name)
+		if err != nil {
+			return nil, err
+		}
+		filter.States = append(filter.States, state)
+	}
+	for _, addr := range f.laddrs {
+		prefix, err := proc.ParsePrefix(addr)
+		if err != nil {
+			return nil, err
+		}
+		filter.LocalNets = append(filter.LocalNets, prefix)
+	}
+	for _, addr := range f.raddrs {
+		prefix, err := proc.ParsePrefix(addr)
+		if err != nil {
+			return nil, err
+		}
+		filter.RemoteNets = append(filter.RemoteNets, prefix)
+	}
+
+	sockets = proc.FilterSockets(filterTypes(sockets, f.types), filter)
+	if f.sort != "" {
+		if err := proc.SortSockets(sockets, f.sort); err != nil {
+			return nil, err
+		}
+	}
+
+	proc.SetResolver(proc.Resolver{Numeric: f.numeric, DNS: f.resolve})
+	if !output.Structured() {
+		proc.PrefetchNames(sockets)
+	}
+	return sockets, nil
+}
+
+// True if any flag narrows the sockets down
+func (f socketFlags) selects() bool {
+	return len(f.types) > 0 || len(f.states) > 0 || len(f.protos) > 0 || len(f.lports) > 0 || len(f.rports) > 0 ||
+		len(f.laddrs) > 0 || len(f.raddrs) > 0 || len(f.inodes) > 0 || f.listening || len(f.uids) > 0
+}
+
+// Keep only the sockets of the given families, all sockets if none are given
+func filterTypes(sockets []proc.Socket, types []string) []proc.Socket {
+	if len(types) == 0 {
+		return sockets
+	}
+	filtered := make([]proc.Socket, 0)
+	for _, s := range sockets {
+		if slices.Contains(types, s.Family) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
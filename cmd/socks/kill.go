@@ -0,0 +1,148 @@
+package socks
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"seer/pkg/proc"
+	"seer/pkg/utils"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// Get the processes holding the socket, excluding seer itself
+func socketHolders(s proc.Socket, procs map[int]proc.Process) []proc.Process {
+	holders := make([]proc.Process, 0)
+	for _, o := range s.Owners {
+		p, exists := procs[o.Pid]
+		if !exists || p.Pid == os.Getpid() {
+			continue
+		}
+		if len(holders) == 0 || holders[len(holders)-1].Pid != p.Pid {
+			holders = append(holders, p)
+		}
+	}
+	return holders
+}
+
+func SocketKill() *cobra.Command {
+	var flags socketFlags
+	var signal_name string
+	var no_signal, yes, dry_run bool
+
+	kill := &cobra.Command{
+		Use:   "kill [inode ...]",
+		Short: "Tear down sockets",
+		Long: `Tear down sockets selected by inode or by the filter flags, ex.
+  seer socks kill --raddr 203.0.113.7 --rport 4444
+
+tcp and udp sockets are destroyed with SOCK_DESTROY, which aborts the connection
+without touching the process holding it. This needs root and a kernel built with
+CONFIG_INET_DIAG_DESTROY. Sockets that can't be destroyed (including unix sockets)
+are torn down by signalling the processes holding them, unless --no-signal is given.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, a := range args {
+				inode, err := strconv.Atoi(a)
+				if err != nil {
+					fmt.Printf("Invalid inode '%s'.\n\n", a)
+					cmd.Help()
+					return
+				}
+				flags.inodes = append(flags.inodes, inode)
+			}
+			// Never tear down every socket on the system by accident
+			if !flags.selects() {
+				fmt.Printf("No sockets selected.\n\n")
+				cmd.Help()
+				return
+			}
+			sig, err := proc.ParseSignal(signal_name)
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			sockets, err := flags.apply(proc.GetSockets())
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+			if len(sockets) == 0 {
+				fmt.Printf("No matching sockets found.\n")
+				return
+			}
+
+			procs := proc.GetProcesses()
+			proc.IndexSockets(procs).Annotate(sockets)
+			fmt.Printf("The following %d socket(s) will be torn down:\n", len(sockets))
+			for _, s := range sockets {
+				fmt.Printf("  %s", s.String())
+				if no_signal {
+					continue
+				}
+				how := "by sending"
+				if s.Destroyable() {
+					how = "if SOCK_DESTROY fails, by sending"
+				}
+				for _, p := range socketHolders(s, procs) {
+					fmt.Printf("    %s %s to %d %s (%s) %s\n", how, proc.SignalName(sig), p.Pid, p.Comm, p.Args(), p.User.Username)
+				}
+			}
+			if dry_run {
+				fmt.Printf("Dry run, no sockets torn down.\n")
+				return
+			}
+			if !yes && !utils.Confirm() {
+				fmt.Printf("Canceled.\n")
+				return
+			}
+
+			ids, err := proc.FindSocketIds(sockets)
+			if err != nil {
+				slog.Warn("Failed to find sockets to destroy", "error", err.Error())
+			}
+			destroyed, signalled := 0, make(map[int]bool)
+			for _, s := range sockets {
+				if s.Destroyable() {
+					err := s.Destroy(ids[s.Inode])
+					if err == nil {
+						destroyed += 1
+						continue
+					}
+					slog.Warn("Failed to destroy socket", "inode", s.Inode, "error", err.Error())
+				}
+				if no_signal {
+					if !s.Destroyable() {
+						slog.Warn("The socket can't be destroyed without signalling its holders", "inode", s.Inode)
+					}
+					continue
+				}
+				holders := socketHolders(s, procs)
+				if len(holders) == 0 {
+					slog.Warn("No process to signal holds the socket", "inode", s.Inode)
+				}
+				for _, p := range holders {
+					if signalled[p.Pid] {
+						continue
+					}
+					if err := p.Signal(sig); err != nil {
+						slog.Error("Failed to signal process", "pid", p.Pid, "error", err.Error())
+					} else {
+						signalled[p.Pid] = true
+					}
+				}
+			}
+			fmt.Printf("Destroyed %d socket(s), signalled %d process(es).\n", destroyed, len(signalled))
+		},
+	}
+
+	flags.add(kill)
+	kill.Flags().StringVar(&signal_name, "signal", "TERM", "signal sent to the holders of sockets that can't be destroyed")
+	kill.Flags().BoolVar(&no_signal, "no-signal", false, "never signal processes, only destroy sockets")
+	kill.Flags().BoolVar(&dry_run, "dry-run", false, "only show the sockets that would be torn down")
+	kill.Flags().BoolVarP(&yes, "yes", "y", false, "respond to prompts with yes")
+
+	return kill
+}
@@ -2,23 +2,98 @@ package socks
 
 import (
 	"fmt"
+	"log/slog"
+	"seer/pkg/network"
+	"seer/pkg/output"
 	"seer/pkg/proc"
+	"slices"
 
 	"github.com/spf13/cobra"
 )
 
+// Print packet sockets along with the processes holding them
+// Raw packet capture is rarely legitimate outside of a few network tools
+func printPacketOwners(s proc.Socket, owners []proc.Process) {
+	if len(owners) == 0 {
+		fmt.Printf("─%s", s.String())
+		return
+	}
+	fmt.Printf("┬%s", s.String())
+	for i, p := range owners {
+		edge := "├"
+		if i == len(owners)-1 {
+			edge = "└"
+		}
+		fmt.Printf("%s─ WARNING: held by %s", edge, p.String())
+	}
+}
+
+// Add the owners of sockets and the addresses wildcard listeners are reachable on
+func annotate(sockets []proc.Socket) {
+	proc.GetSocketIndex().Annotate(sockets)
+	ifaces, err := network.GetInterfaces()
+	if err != nil {
+		slog.Debug("Failed to get interfaces", "error", err.Error())
+		return
+	}
+	network.AnnotateListeners(sockets, ifaces)
+}
+
 func SocketList() *cobra.Command {
+	var flags socketFlags
+
 	list := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List sockets",
+		Long: `List sockets.
+udp and raw sockets are CONNECTED or UNCONNECTED, bound UNCONNECTED sockets receive
+from anyone and count as listening.
+
+The processes holding each socket are listed as pid/program (user), netstat style.
+Sockets without one are marked kernel (ex. TIME_WAIT connections), no process (its
+holder may be hidden) or - when the fds of other users' processes can't be read
+without root. Listeners bound to every address (0.0.0.0 or ::) are followed by the
+addresses of the interfaces they can be reached on.
+
+Ports and addresses are named from /etc/services and /etc/hosts, ss style, unless
+--numeric is given. --resolve also looks up other addresses with reverse DNS, each
+lookup is limited to a second and cached.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			sockets := proc.GetSockets()
+			sockets, err := flags.apply(proc.GetSockets())
+			if err != nil {
+				fmt.Printf("%s\n\n", err.Error())
+				cmd.Help()
+				return
+			}
+
+			annotate(sockets)
+
+			if !slices.Contains(flags.types, "packet") || output.Get() != output.Text {
+				output.Print(sockets, proc.Socket.String)
+				return
+			}
+
+			// Flag the processes holding packet sockets
+			procs := proc.GetProcesses()
 			for _, s := range sockets {
-				fmt.Print(s.String())
+				if s.Family != "packet" {
+					fmt.Print(s.String())
+					continue
+				}
+				owners := make([]proc.Process, 0)
+				for _, o := range s.Owners {
+					p, exists := procs[o.Pid]
+					if exists && !slices.ContainsFunc(owners, func(q proc.Process) bool { return q.Pid == p.Pid }) {
+						owners = append(owners, p)
+					}
+				}
+				printPacketOwners(s, owners)
 			}
 		},
 	}
 
+	flags.add(list)
+
 	return list
 }
@@ -13,6 +13,7 @@ func Socks() *cobra.Command {
 
 	socks.AddCommand(SocketList())
 	socks.AddCommand(SocketDescribe())
+	socks.AddCommand(SocketKill())
 
 	return socks
 }
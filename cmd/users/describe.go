@@ -4,11 +4,23 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"seer/pkg/output"
 	"seer/pkg/users"
+	"sort"
 
 	"github.com/spf13/cobra"
 )
 
+// Get users sorted by uid
+func sortUsers(users_map map[string]users.User) []users.User {
+	user_list := make([]users.User, 0, len(users_map))
+	for _, u := range users_map {
+		user_list = append(user_list, u)
+	}
+	sort.Slice(user_list, func(i, j int) bool { return user_list[i].Uid < user_list[j].Uid })
+	return user_list
+}
+
 func UsersDescribe() *cobra.Command {
 	var regex, iregex bool
 
@@ -23,9 +35,7 @@ Optionally use regex or inverse regex matching to filter users.`,
 				slog.Error("Failed to get users", "error", err.Error())
 			}
 			if len(args) == 0 {
-				for _, u := range users_map {
-					fmt.Print(u.Describe())
-				}
+				output.Print(sortUsers(users_map), users.User.Describe)
 				return
 			}
 			if regex || iregex {
@@ -48,18 +58,18 @@ Optionally use regex or inverse regex matching to filter users.`,
 						}
 					}
 				}
-				for _, u := range matches {
-					fmt.Print(u.Describe())
-				}
+				output.Print(sortUsers(matches), users.User.Describe)
 			} else {
+				found := []users.User{}
 				for _, u := range args {
 					user, exists := users_map[u]
 					if exists {
-						fmt.Print(user.Describe())
+						found = append(found, user)
 					} else {
 						slog.Warn("User does not exist", "user", u)
 					}
 				}
+				output.Print(found, users.User.Describe)
 			}
 		},
 	}
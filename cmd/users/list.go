@@ -2,8 +2,8 @@ package users
 
 import (
 	"fmt"
+	"seer/pkg/output"
 	"seer/pkg/users"
-	"sort"
 
 	"github.com/spf13/cobra"
 )
@@ -18,15 +18,7 @@ func UsersList() *cobra.Command {
 			if err != nil {
 				fmt.Printf("%s\n", err.Error())
 			}
-			users := make([]users.User, 0)
-			for _, u := range users_map {
-				users = append(users, u)
-			}
-
-			sort.Slice(users, func(i, j int) bool { return users[i].Uid < users[j].Uid })
-			for _, u := range users {
-				fmt.Print(u.String())
-			}
+			output.Print(sortUsers(users_map), users.User.String)
 		},
 	}
 
@@ -0,0 +1,88 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"seer/pkg/watch"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func Watch() *cobra.Command {
+	var interval time.Duration
+	var filter string
+	var kinds []string
+
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch for process, socket and user database changes",
+		Long: `Poll the system and print an event for each change:
+  process_start        a new process was started
+  process_exit         a process exited
+  process_exec         a process executed a new program
+  listener_added       a new listening socket was opened
+  outbound_connection  a new connection was initiated from this system
+  userdb_changed       a user or group was added, removed or modified
+
+Processes are tracked by pid and start time so reused pids are reported as new processes.
+Use --output json for one json event per line.
+The --filter flag takes the same expressions as 'seer proc list --filter'.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, k := range kinds {
+				if !slices.Contains(watch.Kinds, k) {
+					fmt.Printf("Unknown event '%s'.\n\n", k)
+					cmd.Help()
+					return
+				}
+			}
+			if interval <= 0 {
+				fmt.Printf("The interval must be positive.\n\n")
+				cmd.Help()
+				return
+			}
+
+			w := watch.Watcher{}
+			if filter != "" {
+				f, err := proc.ParseFilter(filter)
+				if err != nil {
+					fmt.Printf("%s\n\n", err.Error())
+					cmd.Help()
+					return
+				}
+				w.Filter = f
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				for _, e := range w.Poll() {
+					if len(kinds) == 0 || slices.Contains(kinds, e.Kind) {
+						output.Stream(e, e.String())
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	watchCmd.Flags().DurationVarP(&interval, "interval", "i", 2*time.Second, "time between polls")
+	watchCmd.Flags().StringVar(&filter, "filter", "", "only report events for processes matching a filter expression")
+	watchCmd.Flags().StringSliceVarP(&kinds, "events", "e", nil, "only report these kinds of events")
+
+	return watchCmd
+}
@@ -2,10 +2,17 @@ package main
 
 import (
 	"os"
+	"seer/cmd/audit"
 	"seer/cmd/groups"
+	"seer/cmd/network"
 	"seer/cmd/procs"
+	"seer/cmd/snapshot"
 	"seer/cmd/socks"
 	"seer/cmd/users"
+	"seer/cmd/watch"
+	"seer/pkg/output"
+	"seer/pkg/proc"
+	"seer/pkg/sysfs"
 
 	"log/slog"
 
@@ -14,18 +21,32 @@ import (
 
 func main() {
 	var logLevel = new(slog.LevelVar)
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	// Log to stderr so structured output on stdout stays parseable
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
 	slog.SetDefault(logger)
 
 	var verboseLogging bool
+	var outputFormat string
+	var procRoot, etcRoot string
+	var hashAlgorithm string
+	var socketBackend string
 
 	root := &cobra.Command{
 		Use:   "seer",
 		Short: "Seer is a system enumeration and administration tool for linux",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if verboseLogging {
 				logLevel.Set(slog.LevelDebug)
 			}
+			sysfs.Proc = sysfs.Dir(procRoot)
+			sysfs.Etc = sysfs.Dir(etcRoot)
+			if err := proc.SetHashAlgorithm(hashAlgorithm); err != nil {
+				return err
+			}
+			if err := proc.SetSocketBackend(socketBackend); err != nil {
+				return err
+			}
+			return output.Set(outputFormat)
 		},
 	}
 
@@ -33,8 +54,17 @@ func main() {
 	root.AddCommand(groups.Groups())
 	root.AddCommand(procs.Procs())
 	root.AddCommand(socks.Socks())
+	root.AddCommand(network.Network())
+	root.AddCommand(snapshot.Snapshot())
+	root.AddCommand(watch.Watch())
+	root.AddCommand(audit.Audit())
 
 	root.PersistentFlags().BoolVarP(&verboseLogging, "verbose", "v", false, "enable verbose logging")
+	root.PersistentFlags().StringVar(&procRoot, "proc-root", sysfs.DefaultProcRoot, "read process and socket information from this directory")
+	root.PersistentFlags().StringVar(&etcRoot, "etc-root", sysfs.DefaultEtcRoot, "read user and group information from this directory")
+	root.PersistentFlags().StringVar(&hashAlgorithm, "hash", "md5", "algorithm used to hash executables (md5, sha1, sha256)")
+	root.PersistentFlags().StringVar(&socketBackend, "socket-backend", "auto", "read tcp and udp sockets with netlink sock_diag or by parsing /proc/net (auto, netlink, proc)")
+	root.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "output format (text, table, json, yaml)")
 
 	root.Execute()
 }
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"seer/pkg/proc"
+	"seer/pkg/sysfs"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Variables the dynamic linker uses to load extra libraries or change where they are found
+var LinkerEnvVars = []string{"LD_PRELOAD", "LD_LIBRARY_PATH", "LD_AUDIT"}
+
+// Where a preloaded library was found
+const (
+	SourceEnviron = "environ"
+	SourceMaps    = "maps"
+)
+
+// A process with a preloaded library or a linker variable in its environment
+type PreloadFinding struct {
+	Pid    int    `json:"pid"`
+	Comm   string `json:"comm"`
+	User   string `json:"user"`
+	Source string `json:"source"` // environ or maps
+	Detail string `json:"detail"` // The variable or the mapped library and where it was preloaded from
+}
+
+func (f PreloadFinding) Columns() []string {
+	return []string{"PID", "COMM", "USER", "SOURCE", "DETAIL"}
+}
+
+func (f PreloadFinding) Row() []string {
+	return []string{strconv.Itoa(f.Pid), f.Comm, f.User, f.Source, f.Detail}
+}
+
+type PreloadReport struct {
+	SystemPreload []string         `json:"system_preload"` // Libraries listed in /etc/ld.so.preload
+	Findings      []PreloadFinding `json:"findings"`
+}
+
+// Split a list of libraries as used by ld.so.preload and LD_PRELOAD
+// Entries are separated by spaces or colons
+func splitLibraries(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+// Get the libraries listed in /etc/ld.so.preload
+// A missing file is normal and isn't an error
+func SystemPreloads() ([]string, error) {
+	data, err := sysfs.Etc.ReadFile("ld.so.preload")
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read ld.so.preload: %s", err)
+	}
+	libs := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		// Lines starting with # are comments
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		libs = append(libs, splitLibraries(line)...)
+	}
+	return libs, nil
+}
+
+// True if a mapped file is one of the preloaded libraries
+// Libraries given without a directory are searched for by the linker so only the name is compared
+func preloaded(path string, libs []string) bool {
+	for _, lib := range libs {
+		if path == lib || (!strings.Contains(lib, "/") && filepath.Base(path) == lib) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find processes with linker variables in their environment or preloaded libraries in their maps
+func CheckPreload(procs map[int]proc.Process) (PreloadReport, error) {
+	report := PreloadReport{Findings: make([]PreloadFinding, 0)}
+	system, err := SystemPreloads()
+	if err != nil {
+		return report, err
+	}
+	report.SystemPreload = system
+
+	pids := make([]int, 0, len(procs))
+	for pid := range procs {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+
+	for _, pid := range pids {
+		p := procs[pid]
+		finding := func(source string, detail string) {
+			report.Findings = append(report.Findings, PreloadFinding{
+				Pid: p.Pid, Comm: p.Comm, User: p.User.Username, Source: source, Detail: detail,
+			})
+		}
+
+		env_preload := make([]string, 0)
+		env, err := p.Environ(false)
+		if err != nil {
+			slog.Debug("Failed to read process environment", "process", p.Pid, "error", err.Error())
+		}
+		for _, v := range env {
+			if !slices.Contains(LinkerEnvVars, v.Name) || v.Value == "" {
+				continue
+			}
+			finding(SourceEnviron, fmt.Sprintf("%s=%s", v.Name, v.Value))
+			if v.Name == "LD_PRELOAD" {
+				env_preload = append(env_preload, splitLibraries(v.Value)...)
+			}
+		}
+
+		if len(system) == 0 && len(env_preload) == 0 {
+			continue
+		}
+		maps, err := p.Maps()
+		if err != nil {
+			slog.Debug("Failed to read process maps", "process", p.Pid, "error", err.Error())
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, m := range maps {
+			if m.Path == "" || seen[m.Path] {
+				continue
+			}
+			if preloaded(m.Path, env_preload) {
+				seen[m.Path] = true
+				finding(SourceMaps, fmt.Sprintf("%s (from LD_PRELOAD)", m.Path))
+			} else if preloaded(m.Path, system) {
+				seen[m.Path] = true
+				finding(SourceMaps, fmt.Sprintf("%s (from ld.so.preload)", m.Path))
+			}
+		}
+	}
+	return report, nil
+}
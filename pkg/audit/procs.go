@@ -0,0 +1,320 @@
+package audit
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"seer/pkg/proc"
+	"seer/pkg/sysfs"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// How strongly a finding suggests compromise
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+)
+
+var SeverityNames = []string{"low", "medium", "high"}
+
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(SeverityNames) {
+		return SeverityNames[s]
+	}
+	return strconv.Itoa(int(s))
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	*s = parsed
+	return err
+}
+
+func ParseSeverity(name string) (Severity, error) {
+	i := slices.Index(SeverityNames, strings.ToLower(name))
+	if i == -1 {
+		return 0, fmt.Errorf("unknown severity '%s' (expected one of %v)", name, SeverityNames)
+	}
+	return Severity(i), nil
+}
+
+// A check run against every process
+// New checks only need to be added to Heuristics
+type Heuristic struct {
+	Name      string
+	Severity  Severity
+	Rationale string // Why a match is suspicious
+	// Get a detail for each reason the process matches, procs holds every process on the system
+	Check func(p proc.Process, procs map[int]proc.Process) []string
+}
+
+// A process matched by a heuristic
+type Finding struct {
+	Pid       int      `json:"pid"`
+	Comm      string   `json:"comm"`
+	User      string   `json:"user"`
+	Check     string   `json:"check"`
+	Severity  Severity `json:"severity"`
+	Detail    string   `json:"detail"`
+	Rationale string   `json:"rationale"`
+}
+
+func (f Finding) Columns() []string {
+	return []string{"PID", "COMM", "USER", "SEVERITY", "CHECK", "DETAIL"}
+}
+
+func (f Finding) Row() []string {
+	return []string{strconv.Itoa(f.Pid), f.Comm, f.User, f.Severity.String(), f.Check, f.Detail}
+}
+
+// Directories anyone can write to, executables run from these were likely dropped by an attacker
+var WritableDirs = []string{"/tmp/", "/var/tmp/", "/dev/shm/"}
+
+// Names of shells
+var Shells = []string{"sh", "bash", "dash", "zsh", "ksh", "mksh", "csh", "tcsh", "fish", "ash", "busybox"}
+
+// The path of the executable without the deleted marker
+func exePath(p proc.Process) string {
+	return strings.TrimSuffix(p.Exelink, " (deleted)")
+}
+
+// True for kernel threads, which have no executable or command line
+func kernelThread(p proc.Process) bool {
+	return p.Exelink == "" && p.Cmdline == ""
+}
+
+func shell(p proc.Process) bool {
+	return p.Exelink != "" && slices.Contains(Shells, filepath.Base(exePath(p)))
+}
+
+// Get the sockets of the process by inode
+func socketsByInode(p proc.Process) map[string]proc.Socket {
+	sockets := make(map[string]proc.Socket)
+	for _, s := range p.Sockets {
+		sockets[fmt.Sprintf("socket:[%d]", s.Inode)] = s
+	}
+	return sockets
+}
+
+func inet(s proc.Socket) bool {
+	return s.Family == "inet" || s.Family == "inet6"
+}
+
+// True if the executable file has the setuid or setgid bit
+// Files that can't be checked are assumed not to
+func setid(p proc.Process) bool {
+	if !sysfs.LiveProc() {
+		return false
+	}
+	info, err := os.Stat(exePath(p))
+	return err == nil && info.Mode()&(fs.ModeSetuid|fs.ModeSetgid) != 0
+}
+
+var Heuristics = []Heuristic{
+	{
+		Name:      "deleted-exe",
+		Severity:  SeverityHigh,
+		Rationale: "the executable was deleted after the process started, malware does this to avoid disk scans (package upgrades also cause this for long running daemons)",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if p.Exedel {
+				return []string{p.Exelink}
+			}
+			return nil
+		},
+	},
+	{
+		Name:      "writable-dir-exe",
+		Severity:  SeverityHigh,
+		Rationale: "the executable is in a world writable directory, where attackers without root drop their tools",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if p.Exelink == "" {
+				return nil
+			}
+			exe := exePath(p)
+			for _, dir := range WritableDirs {
+				if strings.HasPrefix(exe, dir) {
+					return []string{exe}
+				}
+			}
+			if sysfs.LiveProc() {
+				if info, err := os.Stat(filepath.Dir(exe)); err == nil && info.Mode().Perm()&0o002 != 0 {
+					return []string{exe}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Name:      "comm-mismatch",
+		Severity:  SeverityLow,
+		Rationale: "the process name differs from its executable and command line, which is how processes disguise themselves in ps output",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if p.Exelink == "" {
+				return nil
+			}
+			// comm is the executable or script name truncated to 15 characters
+			names := []string{filepath.Base(exePath(p))}
+			// Scripts are named after the script, the first or second argument
+			args := strings.Split(strings.TrimRight(p.Cmdline, "\x00"), "\x00")
+			for _, arg := range args[:min(len(args), 2)] {
+				// Processes that rewrite their arguments (ex. "sshd: root@pts/0") leave them in one string
+				if words := strings.Fields(arg); len(words) > 0 {
+					names = append(names, filepath.Base(strings.TrimSuffix(words[0], ":")))
+				}
+			}
+			for _, name := range names {
+				if name == p.Comm || (len(p.Comm) == 15 && strings.HasPrefix(name, p.Comm)) {
+					return nil
+				}
+			}
+			return []string{fmt.Sprintf("comm %s but exe %s", p.Comm, exePath(p))}
+		},
+	},
+	{
+		Name:      "kernel-thread-name",
+		Severity:  SeverityHigh,
+		Rationale: "a userland process is named like a kernel thread, which hides it among the kworkers in ps output",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if p.Exelink == "" {
+				return nil
+			}
+			if proc.KernelThreadPattern.MatchString(p.Comm) {
+				return []string{fmt.Sprintf("comm %s with exe %s", p.Comm, p.Exelink)}
+			}
+			if arg0, _, _ := strings.Cut(p.Cmdline, "\x00"); proc.KernelThreadPattern.MatchString(arg0) {
+				return []string{fmt.Sprintf("argv[0] %s with exe %s", arg0, p.Exelink)}
+			}
+			return nil
+		},
+	},
+	{
+		Name:      "shell-network",
+		Severity:  SeverityMedium,
+		Rationale: "shells rarely hold network sockets themselves, a listening or connected shell is often a bind or reverse shell",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if !shell(p) {
+				return nil
+			}
+			details := []string{}
+			// A socket shared by several fds is listed once
+			for _, s := range socketsByInode(p) {
+				if inet(s) {
+					details = append(details, strings.TrimSuffix(s.String(), "\n"))
+				}
+			}
+			sort.Strings(details)
+			return details
+		},
+	},
+	{
+		Name:      "reverse-shell",
+		Severity:  SeverityHigh,
+		Rationale: "the shell reads or writes its standard streams over a network socket, the signature of a reverse or bind shell",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if !shell(p) {
+				return nil
+			}
+			fds, err := p.GetFds()
+			if err != nil {
+				return nil
+			}
+			// The streams are usually all the same socket
+			sockets := socketsByInode(p)
+			streams := make(map[string][]string)
+			targets := []string{}
+			for fd := 0; fd <= 2; fd++ {
+				if s, exists := sockets[fds[fd]]; exists && inet(s) {
+					if len(streams[fds[fd]]) == 0 {
+						targets = append(targets, fds[fd])
+					}
+					streams[fds[fd]] = append(streams[fds[fd]], strconv.Itoa(fd))
+				}
+			}
+			details := []string{}
+			for _, t := range targets {
+				details = append(details, fmt.Sprintf("fds %s are %s", strings.Join(streams[t], ","), strings.TrimSuffix(sockets[t].String(), "\n")))
+			}
+			return details
+		},
+	},
+	{
+		Name:      "uid-mismatch",
+		Severity:  SeverityMedium,
+		Rationale: "the effective user differs from the real user without a setuid executable, which happens after privilege escalation",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			if kernelThread(p) || (p.Uid == p.Euid && p.Gid == p.Egid) || setid(p) {
+				return nil
+			}
+			return []string{fmt.Sprintf("uid %d euid %d gid %d egid %d", p.Uid, p.Euid, p.Gid, p.Egid)}
+		},
+	},
+	{
+		Name:      "orphaned-session",
+		Severity:  SeverityLow,
+		Rationale: "the process was left running by an interactive session and re-parented to pid 1, as done with nohup or a double fork to persist after logout",
+		Check: func(p proc.Process, procs map[int]proc.Process) []string {
+			// Daemons started properly lead their own session
+			if kernelThread(p) || p.Ppid != 1 || p.Session == p.Pid || p.Session == 0 {
+				return nil
+			}
+			leader, exists := procs[p.Session]
+			if p.Tty_nr != 0 {
+				return []string{fmt.Sprintf("still attached to the terminal of session %d", p.Session)}
+			}
+			if !exists {
+				// The login shell that led the session has exited
+				return []string{fmt.Sprintf("from session %d whose leader has exited", p.Session)}
+			}
+			if leader.Tty_nr != 0 {
+				return []string{fmt.Sprintf("from session %d of %s", p.Session, leader.Comm)}
+			}
+			return nil
+		},
+	},
+}
+
+// Get the names of the heuristics
+func HeuristicNames() []string {
+	names := make([]string, 0, len(Heuristics))
+	for _, h := range Heuristics {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+// Run heuristics against every process and get the findings at or above min
+// Findings are sorted by pid and then by descending severity
+func CheckProcesses(procs map[int]proc.Process, heuristics []Heuristic, min Severity) []Finding {
+	findings := make([]Finding, 0)
+	for _, p := range procs {
+		for _, h := range heuristics {
+			if h.Severity < min {
+				continue
+			}
+			for _, detail := range h.Check(p, procs) {
+				findings = append(findings, Finding{
+					Pid: p.Pid, Comm: p.Comm, User: p.User.Username,
+					Check: h.Name, Severity: h.Severity, Detail: detail, Rationale: h.Rationale,
+				})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Pid != findings[j].Pid {
+			return findings[i].Pid < findings[j].Pid
+		}
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings
+}
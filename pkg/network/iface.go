@@ -0,0 +1,217 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"seer/pkg/sysfs"
+	"seer/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Interfaces are read from here, /proc/net/dev is read through sysfs.Proc
+const sysClassNet = "/sys/class/net"
+
+// IFF_UP and IFF_PROMISC from linux/if.h
+const (
+	iffUp      = 0x1
+	iffPromisc = 0x100
+)
+
+// A network interface
+type Interface struct {
+	Name        string   `json:"name"`
+	Index       int      `json:"index"`
+	Mac         string   `json:"mac"`
+	Mtu         int      `json:"mtu"`
+	State       string   `json:"state"` // Operational state, ex. up, down or unknown (usual for loopback)
+	Flags       int      `json:"flags"` // IFF_* flags
+	Promiscuous bool     `json:"promiscuous"`
+	Addresses   []string `json:"addresses"` // In CIDR form, link local ipv6 addresses include the zone
+
+	// Counters from /proc/net/dev
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	TxPackets uint64 `json:"tx_packets"`
+	TxErrors  uint64 `json:"tx_errors"`
+	TxDropped uint64 `json:"tx_dropped"`
+}
+
+// True if the interface is up, loopback reports an unknown operational state
+func (i Interface) Up() bool {
+	return i.Flags&iffUp != 0 && i.State != "down" && i.State != "lowerlayerdown"
+}
+
+func (i Interface) String() string {
+	addrs := strings.Join(i.Addresses, ",")
+	if addrs == "" {
+		addrs = "-"
+	}
+	warning := ""
+	if i.Promiscuous {
+		warning = " WARNING: promiscuous, the interface may be sniffed"
+	}
+	return fmt.Sprintf("[%d] %s %s mtu:%d %s %s rx: %s tx: %s%s\n",
+		i.Index,
+		i.Name,
+		i.Mac,
+		i.Mtu,
+		i.State,
+		addrs,
+		utils.FormatBytes(i.RxBytes),
+		utils.FormatBytes(i.TxBytes),
+		warning,
+	)
+}
+
+func (i Interface) Describe() string {
+	desc := fmt.Sprintf("┌[%d] %s\n", i.Index, i.Name)
+	desc += fmt.Sprintf("├ MAC: %s\n", i.Mac)
+	desc += fmt.Sprintf("├ MTU: %d\n", i.Mtu)
+	desc += fmt.Sprintf("├ State: %s (flags: 0x%x)\n", i.State, i.Flags)
+	if i.Promiscuous {
+		desc += "├ WARNING: promiscuous, the interface may be sniffed\n"
+	}
+	if len(i.Addresses) == 0 {
+		desc += "├ Addresses: none\n"
+	} else {
+		desc += "├┬Addresses:\n"
+		for n, addr := range i.Addresses {
+			edge := "├"
+			if n == len(i.Addresses)-1 {
+				edge = "└"
+			}
+			desc += fmt.Sprintf("│%s %s\n", edge, addr)
+		}
+	}
+	desc += fmt.Sprintf("├ RX: %s in %d packets, %d errors, %d dropped\n", utils.FormatBytes(i.RxBytes), i.RxPackets, i.RxErrors, i.RxDropped)
+	desc += fmt.Sprintf("└ TX: %s in %d packets, %d errors, %d dropped\n", utils.FormatBytes(i.TxBytes), i.TxPackets, i.TxErrors, i.TxDropped)
+	return desc
+}
+
+func (i Interface) Columns() []string {
+	return []string{"INDEX", "NAME", "MAC", "MTU", "STATE", "ADDRESSES", "RX", "TX", "PROMISC"}
+}
+
+func (i Interface) Row() []string {
+	return []string{
+		strconv.Itoa(i.Index),
+		i.Name,
+		i.Mac,
+		strconv.Itoa(i.Mtu),
+		i.State,
+		strings.Join(i.Addresses, ","),
+		utils.FormatBytes(i.RxBytes),
+		utils.FormatBytes(i.TxBytes),
+		strconv.FormatBool(i.Promiscuous),
+	}
+}
+
+// Read a single value file of an interface from /sys/class/net/<name>
+func readAttr(name string, attr string) string {
+	data, err := os.ReadFile(filepath.Join(sysClassNet, name, attr))
+	if err != nil {
+		// Some attributes can't be read while the interface is down
+		slog.Debug("Failed to read interface attribute", "interface", name, "attribute", attr, "error", err.Error())
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Read the counters of every interface from /proc/net/dev, keyed by interface name
+func readCounters() map[string][]uint64 {
+	counters := make(map[string][]uint64)
+	data, err := sysfs.Proc.ReadFile("net/dev")
+	if err != nil {
+		slog.Debug("Failed to read file", "path", "net/dev", "error", err.Error())
+		return counters
+	}
+	// Two header lines, then "name: rx bytes packets errs drop fifo frame compressed multicast tx bytes packets errs drop ..."
+	for _, line := range strings.Split(string(data), "\n") {
+		name, values, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(values)
+		if len(fields) < 16 {
+			continue
+		}
+		numbers := make([]uint64, len(fields))
+		for n, f := range fields {
+			numbers[n], _ = strconv.ParseUint(f, 10, 64)
+		}
+		counters[strings.TrimSpace(name)] = numbers
+	}
+	return counters
+}
+
+// Get the addresses of an interface in CIDR form
+func interfaceAddrs(index int) []string {
+	addrs := make([]string, 0)
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		slog.Debug("Failed to get interface", "index", index, "error", err.Error())
+		return addrs
+	}
+	ifaddrs, err := iface.Addrs()
+	if err != nil {
+		slog.Debug("Failed to get interface addresses", "interface", iface.Name, "error", err.Error())
+		return addrs
+	}
+	for _, a := range ifaddrs {
+		prefix, err := netip.ParsePrefix(a.String())
+		if err != nil {
+			continue
+		}
+		addr := prefix.Addr()
+		if addr.Is6() && addr.IsLinkLocalUnicast() {
+			// Prefixes can't hold a zone
+			addr = addr.WithZone(iface.Name)
+		}
+		addrs = append(addrs, fmt.Sprintf("%s/%d", addr, prefix.Bits()))
+	}
+	return addrs
+}
+
+// Get the network interfaces of the running system sorted by index
+func GetInterfaces() ([]Interface, error) {
+	if !sysfs.LiveProc() {
+		// Interfaces of the running system don't apply to an alternate /proc
+		return nil, errors.New("interfaces can only be read from the running system")
+	}
+	entries, err := os.ReadDir(sysClassNet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces: %s", err)
+	}
+
+	counters := readCounters()
+	ifaces := make([]Interface, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		i := Interface{Name: name, Mac: readAttr(name, "address"), State: readAttr(name, "operstate")}
+		i.Index, _ = strconv.Atoi(readAttr(name, "ifindex"))
+		i.Mtu, _ = strconv.Atoi(readAttr(name, "mtu"))
+		flags, _ := strconv.ParseInt(readAttr(name, "flags"), 0, 64)
+		i.Flags = int(flags)
+		// The flags in sysfs include promiscuous mode requested by packet sockets (ex. tcpdump),
+		// which the SIOCGIFFLAGS ioctl used by ifconfig hides
+		i.Promiscuous = i.Flags&iffPromisc != 0
+		i.Addresses = interfaceAddrs(i.Index)
+		if c, exists := counters[name]; exists {
+			i.RxBytes, i.RxPackets, i.RxErrors, i.RxDropped = c[0], c[1], c[2], c[3]
+			i.TxBytes, i.TxPackets, i.TxErrors, i.TxDropped = c[8], c[9], c[10], c[11]
+		}
+		ifaces = append(ifaces, i)
+	}
+	sort.Slice(ifaces, func(a, b int) bool { return ifaces[a].Index < ifaces[b].Index })
+	return ifaces, nil
+}
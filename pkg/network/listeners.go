@@ -0,0 +1,55 @@
+package network
+
+import (
+	"net/netip"
+	"seer/pkg/proc"
+	"seer/pkg/sysfs"
+	"strings"
+)
+
+// True if ipv6 sockets only accept ipv6 by default (net.ipv6.bindv6only)
+func bindV6Only() bool {
+	data, err := sysfs.Proc.ReadFile("sys/net/ipv6/bindv6only")
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
+// Get the addresses of the interfaces that are up, optionally of one family
+func upAddrs(ifaces []Interface, v4 bool, v6 bool) []string {
+	addrs := make([]string, 0)
+	for _, i := range ifaces {
+		if !i.Up() {
+			continue
+		}
+		for _, a := range i.Addresses {
+			// Link local addresses have a zone, which netip.ParsePrefix rejects
+			value, _, _ := strings.Cut(a, "/")
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				continue
+			}
+			if (addr.Is4() && v4) || (addr.Is6() && v6) {
+				addrs = append(addrs, addr.String())
+			}
+		}
+	}
+	return addrs
+}
+
+// Set the concrete addresses listeners bound to the wildcard address (0.0.0.0 or ::)
+// are reachable on, from the addresses of the interfaces that are up
+// ipv6 listeners also accept ipv4 unless net.ipv6.bindv6only is set, a socket
+// setting IPV6_V6ONLY itself can't be told apart
+func AnnotateListeners(sockets []proc.Socket, ifaces []Interface) {
+	v6only := bindV6Only()
+	for n, s := range sockets {
+		addr, err := netip.ParseAddr(s.Local_addr)
+		if err != nil || !addr.IsUnspecified() || !s.Listening() {
+			continue
+		}
+		if s.Family == "inet" {
+			sockets[n].Reachable = upAddrs(ifaces, true, false)
+		} else {
+			sockets[n].Reachable = upAddrs(ifaces, !v6only, true)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	Text  Format = "text"  // The default human readable format
+	Table Format = "table" // Aligned columns with a header row
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+)
+
+var Formats = []Format{Text, Table, JSON, YAML}
+
+// Types that can be printed as a row in the table format
+type Tabular interface {
+	Columns() []string
+	Row() []string
+}
+
+var current = Text
+
+// Set the output format used by Print and Encode
+func Set(format string) error {
+	for _, f := range Formats {
+		if string(f) == format {
+			current = f
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s' (expected one of %v)", format, Formats)
+}
+
+func Get() Format {
+	return current
+}
+
+// True if the selected format is meant to be read by other programs
+func Structured() bool {
+	return current == JSON || current == YAML
+}
+
+// Print a list of items in the selected format
+// text is used to render each item in the text format
+func Print[T Tabular](items []T, text func(T) string) {
+	switch current {
+	case JSON, YAML:
+		if items == nil {
+			items = []T{}
+		}
+		Encode(items)
+	case Table:
+		PrintTable(items)
+	default:
+		for _, i := range items {
+			fmt.Print(text(i))
+		}
+	}
+}
+
+// Print items as aligned columns with a header taken from the first item
+func PrintTable[T Tabular](items []T) {
+	if len(items) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(items[0].Columns(), "\t"))
+	for _, i := range items {
+		fmt.Fprintln(w, strings.Join(i.Row(), "\t"))
+	}
+	w.Flush()
+}
+
+// Write v to stdout as json or yaml depending on the selected format
+func Encode(v any) error {
+	var data []byte
+	var err error
+	if current == YAML {
+		data, err = toYAML(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %s", err)
+	}
+	os.Stdout.Write(data)
+	return nil
+}
+
+// Print a single item of a stream such as an event log
+// json items are written one per line and yaml items as separate documents
+func Stream(v any, text string) error {
+	switch current {
+	case JSON:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %s", err)
+		}
+		os.Stdout.Write(append(data, '\n'))
+	case YAML:
+		data, err := toYAML(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %s", err)
+		}
+		fmt.Printf("---\n%s", data)
+	default:
+		fmt.Print(text)
+	}
+	return nil
+}
+
+// The json tags are the single source of truth for the schema,
+// so yaml is produced by re-encoding the json document
+func toYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearStyle(&node)
+	return yaml.Marshal(&node)
+}
+
+// Drop the flow style and quoting carried over from json
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, c := range node.Content {
+		clearStyle(c)
+	}
+}
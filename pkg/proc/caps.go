@@ -0,0 +1,162 @@
+package proc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A set of capabilities as found in the Cap* lines of /proc/[pid]/status
+type Capabilities uint64
+
+// Capability names indexed by bit number, see capabilities(7)
+var CapabilityNames = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// Capabilities that allow a process to take over the system or read other users' data
+var DangerousCapabilities = []string{
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_ADMIN",
+	"CAP_SETFCAP",
+	"CAP_MAC_ADMIN",
+	"CAP_MAC_OVERRIDE",
+	"CAP_BPF",
+}
+
+// Parse a hex capability mask from /proc/[pid]/status
+func parseCapabilities(mask string) Capabilities {
+	caps, err := strconv.ParseUint(mask, 16, 64)
+	if err != nil {
+		slog.Debug("Failed to parse capabilities", "mask", mask, "error", err.Error())
+	}
+	return Capabilities(caps)
+}
+
+// Get the names of the capabilities in the set
+// Bits without a known name are shown by number
+func (c Capabilities) Names() []string {
+	names := make([]string, 0)
+	for i := 0; i < 64; i++ {
+		if c&(1<<i) == 0 {
+			continue
+		}
+		if i < len(CapabilityNames) {
+			names = append(names, CapabilityNames[i])
+		} else {
+			names = append(names, fmt.Sprintf("CAP_%d", i))
+		}
+	}
+	return names
+}
+
+// Get the dangerous capabilities in the set
+func (c Capabilities) Dangerous() []string {
+	found := make([]string, 0)
+	for _, n := range c.Names() {
+		if slices.Contains(DangerousCapabilities, n) {
+			found = append(found, n)
+		}
+	}
+	return found
+}
+
+// True if the set contains every known capability, as it does for root
+func (c Capabilities) Full() bool {
+	all := Capabilities(1<<len(CapabilityNames) - 1)
+	return c&all == all
+}
+
+func (c Capabilities) String() string {
+	if c == 0 {
+		return "none"
+	}
+	if c.Full() {
+		return "all"
+	}
+	// Sets missing only a few capabilities are easier to read by what they lack
+	all := Capabilities(1<<len(CapabilityNames) - 1)
+	if missing := (all &^ c).Names(); len(missing) <= 5 {
+		return "all except " + strings.Join(missing, ",")
+	}
+	return strings.Join(c.Names(), ",")
+}
+
+// Capabilities are encoded as a list of names
+func (c Capabilities) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Names())
+}
+
+func (c *Capabilities) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*c = 0
+	for _, n := range names {
+		i := slices.Index(CapabilityNames, n)
+		if i == -1 {
+			if _, err := fmt.Sscanf(n, "CAP_%d", &i); err != nil {
+				return fmt.Errorf("unknown capability '%s'", n)
+			}
+			// Capability sets are 64 bits wide
+			if i < 0 || i > 63 {
+				return fmt.Errorf("capability '%s' out of range", n)
+			}
+		}
+		*c |= 1 << i
+	}
+	return nil
+}
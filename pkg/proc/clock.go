@@ -0,0 +1,74 @@
+package proc
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seer/pkg/sysfs"
+)
+
+// Clock ticks per second used when the real rate can't be read
+// This is the USER_HZ of every mainstream architecture
+const DefaultClockTicks = 100
+
+// The AT_CLKTCK entry of the auxiliary vector holds the tick rate of the times in /proc/[pid]/stat
+const atClkTck = 17
+
+// Get the clock ticks per second used by the time fields in /proc/[pid]/stat
+// The rate is read from the auxiliary vector the kernel passed to a process
+var ClockTicks = sync.OnceValue(func() uint64 {
+	for _, name := range []string{"self/auxv", "1/auxv"} {
+		data, err := sysfs.Proc.ReadFile(name)
+		if err != nil {
+			slog.Debug("Failed to read the auxiliary vector", "file", name, "error", err.Error())
+			continue
+		}
+		// Entries are pairs of native words, only 64 bit systems are supported
+		for i := 0; i+16 <= len(data); i += 16 {
+			key := binary.NativeEndian.Uint64(data[i:])
+			value := binary.NativeEndian.Uint64(data[i+8:])
+			if key == atClkTck && value > 0 {
+				return value
+			}
+		}
+	}
+	return DefaultClockTicks
+})
+
+// Get the time the system booted from the btime line of /proc/stat
+// The zero time is returned if it can't be read
+var BootTime = sync.OnceValue(func() time.Time {
+	data, err := sysfs.Proc.ReadFile("stat")
+	if err != nil {
+		slog.Debug("Failed to read /proc/stat", "error", err.Error())
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, found := strings.CutPrefix(line, "btime "); found {
+			btime, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err == nil {
+				return time.Unix(btime, 0)
+			}
+		}
+	}
+	return time.Time{}
+})
+
+// Convert clock ticks to seconds
+func ticksToSeconds(ticks uint64) float64 {
+	return float64(ticks) / float64(ClockTicks())
+}
+
+// Convert a start time in clock ticks since boot to an absolute time
+// The zero time is returned if the boot time is unknown
+func startedAt(starttime uint64) time.Time {
+	boot := BootTime()
+	if boot.IsZero() {
+		return boot
+	}
+	return boot.Add(time.Duration(ticksToSeconds(starttime) * float64(time.Second))).Truncate(time.Millisecond)
+}
@@ -0,0 +1,122 @@
+package proc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seer/pkg/sysfs"
+)
+
+// Namespaces read from /proc/[pid]/ns
+var NamespaceTypes = []string{"cgroup", "ipc", "mnt", "net", "pid", "time", "user", "uts"}
+
+// Namespaces that are compared with the parent process
+// A process in a different one than its parent may be a container or be hiding from the host
+var WatchedNamespaces = []string{"pid", "net", "mnt"}
+
+// The container a process runs in
+type Container struct {
+	Runtime string `json:"runtime"` // docker, containerd, podman, cri-o, systemd-nspawn or kubernetes if the runtime is unknown
+	Id      string `json:"id"`      // Container id or machine name
+}
+
+// Short form of the id, as shown by docker and podman
+func (c Container) ShortId() string {
+	if len(c.Id) > 12 {
+		return c.Id[:12]
+	}
+	return c.Id
+}
+
+func (c Container) String() string {
+	return fmt.Sprintf("%s:%s", c.Runtime, c.ShortId())
+}
+
+// Patterns matching cgroup paths created by container runtimes
+// The first match is used so more specific patterns come first
+var containerPatterns = []struct {
+	re      *regexp.Regexp
+	runtime string
+}{
+	{regexp.MustCompile(`libpod-(?:conmon-)?([0-9a-f]{64})`), "podman"},
+	{regexp.MustCompile(`docker-([0-9a-f]{64})\.scope`), "docker"},
+	{regexp.MustCompile(`/docker/([0-9a-f]{64})`), "docker"},
+	{regexp.MustCompile(`cri-containerd-([0-9a-f]{64})`), "containerd"},
+	{regexp.MustCompile(`crio-(?:conmon-)?([0-9a-f]{64})`), "cri-o"},
+	// Kubernetes with the cgroupfs driver doesn't name the runtime
+	{regexp.MustCompile(`/kubepods[^:]*/([0-9a-f]{64})$`), "kubernetes"},
+	{regexp.MustCompile(`systemd-nspawn@([^/]+)\.service`), "systemd-nspawn"},
+	{regexp.MustCompile(`machine\.slice/machine-([^/]+)\.scope`), "systemd-nspawn"},
+}
+
+// Find the container from a process' cgroup paths
+func findContainer(cgroups []string) *Container {
+	for _, pattern := range containerPatterns {
+		for _, cg := range cgroups {
+			if m := pattern.re.FindStringSubmatch(cg); m != nil {
+				// systemd escapes dashes in unit names
+				return &Container{Runtime: pattern.runtime, Id: strings.ReplaceAll(m[1], `\x2d`, "-")}
+			}
+		}
+	}
+	return nil
+}
+
+// Read the cgroup paths of a process from /proc/[pid]/cgroup
+// Each line is hierarchy-id:controllers:path and only the distinct paths are kept
+func readCgroups(pid int) []string {
+	data, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/cgroup", pid))
+	if err != nil {
+		return nil
+	}
+	paths := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if !slices.Contains(paths, parts[2]) {
+			paths = append(paths, parts[2])
+		}
+	}
+	return paths
+}
+
+// Read the namespace inodes of a process from the links in /proc/[pid]/ns
+func readNamespaces(pid int) map[string]uint64 {
+	namespaces := make(map[string]uint64)
+	for _, ns := range NamespaceTypes {
+		// Links look like net:[4026531840]
+		link, err := sysfs.Proc.ReadLink(fmt.Sprintf("%d/ns/%s", pid, ns))
+		if err != nil {
+			continue
+		}
+		start := strings.IndexRune(link, '[')
+		end := strings.IndexRune(link, ']')
+		if start == -1 || end < start {
+			continue
+		}
+		inode, err := strconv.ParseUint(link[start+1:end], 10, 64)
+		if err == nil {
+			namespaces[ns] = inode
+		}
+	}
+	return namespaces
+}
+
+// Get the watched namespaces that differ between the process and its parent
+// Namespaces that couldn't be read for either process are skipped
+func (p Process) namespaceChanges(parent Process) []string {
+	changed := make([]string, 0)
+	for _, ns := range WatchedNamespaces {
+		mine, ok := p.Namespaces[ns]
+		theirs, parent_ok := parent.Namespaces[ns]
+		if ok && parent_ok && mine != theirs {
+			changed = append(changed, ns)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,29 @@
+package proc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindContainer(t *testing.T) {
+	id := "4f1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c"
+	tests := []struct {
+		cgroup string
+		want   *Container
+	}{
+		{"/system.slice/docker-" + id + ".scope", &Container{"docker", id}},
+		{"/docker/" + id, &Container{"docker", id}},
+		{"/machine.slice/libpod-" + id + ".scope/container", &Container{"podman", id}},
+		{"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope", &Container{"containerd", id}},
+		{"/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod1234.slice/crio-" + id + ".scope", &Container{"cri-o", id}},
+		{"/kubepods.slice/kubepods-pod1234.slice/docker-" + id + ".scope", &Container{"docker", id}},
+		{"/kubepods/burstable/pod1234/" + id, &Container{"kubernetes", id}},
+		{`/machine.slice/systemd-nspawn@web\x2d1.service/payload`, &Container{"systemd-nspawn", "web-1"}},
+		{"/user.slice/user-1000.slice/session-2.scope", nil},
+	}
+	for _, tt := range tests {
+		if got := findContainer([]string{tt.cgroup}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findContainer(%q) = %+v, want %+v", tt.cgroup, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"seer/pkg/sysfs"
+	"syscall"
+)
+
+// Protocol numbers of the inet protocols sock_diag can destroy
+var diagProtocols = map[string]uint8{
+	"tcp":     syscall.IPPROTO_TCP,
+	"udp":     syscall.IPPROTO_UDP,
+	"udplite": 136, // IPPROTO_UDPLITE
+}
+
+const (
+	inetDiagReqLength = 56 // struct inet_diag_req_v2
+	inetDiagIdLength  = 48 // struct inet_diag_sockid
+	inetDiagMsgLength = 72 // struct inet_diag_msg
+)
+
+// Build a struct inet_diag_req_v2 selecting sockets in any state
+// id is a struct inet_diag_sockid naming a single socket, or nil for a dump
+func inetDiagRequest(family uint8, protocol uint8, id []byte) []byte {
+	request := make([]byte, inetDiagReqLength)
+	request[0] = family
+	request[1] = protocol
+	binary.NativeEndian.PutUint32(request[4:8], 0xffffffff) // all states
+	copy(request[8:], id)
+	return request
+}
+
+// True if sockets of the protocol can be destroyed with SOCK_DESTROY
+func (s Socket) Destroyable() bool {
+	_, supported := diagProtocols[s.Protocol]
+	return supported && (s.Family == "inet" || s.Family == "inet6") && s.Inode != 0
+}
+
+// A struct inet_diag_sockid naming a single socket
+type SocketId []byte
+
+// The address family and protocol numbers sock_diag uses for the socket
+func (s Socket) diagFamily() (uint8, uint8) {
+	family := uint8(syscall.AF_INET)
+	if s.Family == "inet6" {
+		family = syscall.AF_INET6
+	}
+	return family, diagProtocols[s.Protocol]
+}
+
+// Get the ids of the destroyable sockets keyed by inode
+// The kernel finds sockets to destroy by their exact id, which is simplest to take
+// from a dump, so each family and protocol is dumped once for all the sockets
+func FindSocketIds(sockets []Socket) (map[int]SocketId, error) {
+	ids := make(map[int]SocketId)
+	dumped := make(map[[2]uint8]bool)
+	for _, s := range sockets {
+		if !s.Destroyable() {
+			continue
+		}
+		family, protocol := s.diagFamily()
+		if dumped[[2]uint8{family, protocol}] {
+			continue
+		}
+		dumped[[2]uint8{family, protocol}] = true
+		msgs, err := inetDiagDump(family, protocol, 0)
+		if err != nil {
+			return ids, fmt.Errorf("failed to dump %s sockets: %s", s.ProtocolName(), err)
+		}
+		for _, m := range msgs {
+			if len(m) >= inetDiagMsgLength {
+				ids[int(binary.NativeEndian.Uint32(m[68:72]))] = SocketId(m[4 : 4+inetDiagIdLength])
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Close the socket from the kernel side with SOCK_DESTROY, both ends see the
+// connection aborted and the process holding it gets an error on its next use
+// id is the socket's entry from FindSocketIds, nil if it wasn't found
+// This needs CAP_NET_ADMIN and a kernel built with CONFIG_INET_DIAG_DESTROY
+func (s Socket) Destroy(id SocketId) error {
+	if !sysfs.LiveProc() {
+		return errors.New("refusing to destroy sockets read from an alternate /proc")
+	}
+	if !s.Destroyable() {
+		return fmt.Errorf("%s sockets can't be destroyed", s.ProtocolName())
+	}
+	if id == nil {
+		return fmt.Errorf("the socket '%d' no longer exists", s.Inode)
+	}
+
+	family, protocol := s.diagFamily()
+	if _, err := sockDiag(sockDestroy, inetDiagRequest(family, protocol, id), syscall.NLM_F_ACK); err != nil {
+		if errors.Is(err, syscall.EOPNOTSUPP) {
+			return fmt.Errorf("failed to destroy socket '%d': the kernel doesn't support SOCK_DESTROY", s.Inode)
+		}
+		return fmt.Errorf("failed to destroy socket '%d': %s", s.Inode, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Minimal NETLINK_SOCK_DIAG client
+// https://man7.org/linux/man-pages/man7/sock_diag.7.html
+
+const (
+	netlinkSockDiag    = 4  // NETLINK_SOCK_DIAG
+	sockDiagByFamily   = 20 // SOCK_DIAG_BY_FAMILY
+	sockDestroy        = 21 // SOCK_DESTROY
+	nlmsgHeaderLength  = 16
+	nlattrHeaderLength = 4
+)
+
+// A netlink attribute (struct nlattr) from a sock_diag response
+type diagAttr struct {
+	Type uint16
+	Data []byte
+}
+
+// Send a sock_diag request and collect the payload of every response message
+// The payloads start with the family specific message (ex. struct unix_diag_msg)
+// msgType is SOCK_DIAG_BY_FAMILY for queries or SOCK_DESTROY
+func sockDiag(msgType uint16, request []byte, flags uint16) ([][]byte, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, netlinkSockDiag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sock_diag socket: %s", err)
+	}
+	defer syscall.Close(fd)
+
+	addr := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+	if err := syscall.Bind(fd, addr); err != nil {
+		return nil, fmt.Errorf("failed to bind sock_diag socket: %s", err)
+	}
+
+	seq := uint32(os.Getpid())
+	msg := make([]byte, nlmsgHeaderLength, nlmsgHeaderLength+len(request))
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(nlmsgHeaderLength+len(request)))
+	binary.NativeEndian.PutUint16(msg[4:6], msgType)
+	binary.NativeEndian.PutUint16(msg[6:8], syscall.NLM_F_REQUEST|flags)
+	binary.NativeEndian.PutUint32(msg[8:12], seq)
+	msg = append(msg, request...)
+
+	if err := syscall.Sendto(fd, msg, 0, addr); err != nil {
+		return nil, fmt.Errorf("failed to send sock_diag request: %s", err)
+	}
+
+	payloads := make([][]byte, 0)
+	for {
+		// Payloads point into the buffer, so each read gets its own rather than
+		// overwriting the messages of the previous one
+		buf := make([]byte, 64*1024)
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sock_diag response: %s", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sock_diag response: %s", err)
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return payloads, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+						return payloads, syscall.Errno(-errno)
+					}
+				}
+				// An error message with errno 0 acknowledges a request
+				return payloads, nil
+			default:
+				payloads = append(payloads, m.Data)
+			}
+		}
+		if flags&syscall.NLM_F_DUMP == 0 && len(payloads) > 0 {
+			return payloads, nil
+		}
+	}
+}
+
+// Parse the attributes following a fixed size sock_diag message
+func parseDiagAttrs(data []byte) (attrs []diagAttr) {
+	for len(data) >= nlattrHeaderLength {
+		length := int(binary.NativeEndian.Uint16(data[0:2]))
+		if length < nlattrHeaderLength || length > len(data) {
+			break
+		}
+		attrs = append(attrs, diagAttr{
+			Type: binary.NativeEndian.Uint16(data[2:4]),
+			Data: data[nlattrHeaderLength:length],
+		})
+		// Attributes are aligned to 4 bytes
+		aligned := (length + 3) &^ 3
+		if aligned > len(data) {
+			break
+		}
+		data = data[aligned:]
+	}
+	return attrs
+}
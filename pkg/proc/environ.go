@@ -0,0 +1,61 @@
+package proc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"seer/pkg/sysfs"
+)
+
+// An environment variable of a process
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (e EnvVar) String() string {
+	return fmt.Sprintf("%s=%s\n", e.Name, e.Value)
+}
+
+func (e EnvVar) Columns() []string {
+	return []string{"NAME", "VALUE"}
+}
+
+func (e EnvVar) Row() []string {
+	return []string{e.Name, e.Value}
+}
+
+// Shown in place of the values of secret variables
+const Redacted = "<redacted>"
+
+// Names of variables that likely hold secrets
+var secretEnvPattern = regexp.MustCompile(`(?i)(pass|secret|token|key|credential|auth|cookie|session|private)`)
+
+// True if the variable likely holds a secret such as a password or api key
+func (e EnvVar) Secret() bool {
+	return secretEnvPattern.MatchString(e.Name)
+}
+
+// Get the environment of the process from /proc/[pid]/environ
+// This is the environment the process started with, changes made by the process itself aren't visible
+// With redact the values of variables that look like secrets are replaced
+func (p Process) Environ(redact bool) ([]EnvVar, error) {
+	data, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/environ", p.Pid))
+	if err != nil {
+		return nil, err
+	}
+	env := make([]EnvVar, 0)
+	for _, entry := range strings.Split(string(data), "\x00") {
+		if entry == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(entry, "=")
+		v := EnvVar{Name: name, Value: value}
+		if redact && v.Secret() {
+			v.Value = Redacted
+		}
+		env = append(env, v)
+	}
+	return env, nil
+}
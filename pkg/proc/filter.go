@@ -0,0 +1,421 @@
+package proc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A process filter parsed from an expression such as:
+//
+//	uid >= 1000 and exedel and socket.state == LISTEN
+//	comm =~ "^(nc|ncat|socat)$" or (user != root && age < 10m)
+//
+// Comparisons: == (or =), !=, <, <=, >, >=, =~ (regex match), !~ (regex non-match)
+// Boolean operators: and (&&), or (||), not (!) and parentheses
+// Values containing spaces, parentheses or operators must be quoted.
+// Comparisons on socket fields match if any socket of the process matches.
+type Filter interface {
+	Match(p Process) bool
+}
+
+type fieldKind int
+
+const (
+	numericField fieldKind = iota
+	textField
+	flagField
+)
+
+type filterField struct {
+	kind fieldKind
+	num  func(p Process) []int64
+	text func(p Process) []string
+	flag func(p Process) bool
+	help string
+}
+
+func procNumeric(help string, get func(p Process) int64) filterField {
+	return filterField{kind: numericField, help: help, num: func(p Process) []int64 { return []int64{get(p)} }}
+}
+
+func procText(help string, get func(p Process) string) filterField {
+	return filterField{kind: textField, help: help, text: func(p Process) []string { return []string{get(p)} }}
+}
+
+func socketNumeric(help string, get func(s Socket) int64) filterField {
+	return filterField{kind: numericField, help: help, num: func(p Process) (values []int64) {
+		for _, s := range p.Sockets {
+			values = append(values, get(s))
+		}
+		return values
+	}}
+}
+
+func socketText(help string, get func(s Socket) string) filterField {
+	return filterField{kind: textField, help: help, text: func(p Process) (values []string) {
+		for _, s := range p.Sockets {
+			values = append(values, get(s))
+		}
+		return values
+	}}
+}
+
+// Fields that can be used in filter expressions
+var FilterFields = map[string]filterField{
+	"pid":     procNumeric("process id", func(p Process) int64 { return int64(p.Pid) }),
+	"ppid":    procNumeric("parent process id", func(p Process) int64 { return int64(p.Ppid) }),
+	"uid":     procNumeric("real user id", func(p Process) int64 { return int64(p.Uid) }),
+	"euid":    procNumeric("effective user id", func(p Process) int64 { return int64(p.Euid) }),
+	"suid":    procNumeric("saved user id", func(p Process) int64 { return int64(p.Suid) }),
+	"fuid":    procNumeric("filesystem user id", func(p Process) int64 { return int64(p.Fuid) }),
+	"age":     procNumeric("age in seconds, also accepts durations such as 10m", func(p Process) int64 { return int64(p.Age()) }),
+	"gid":     procNumeric("real group id", func(p Process) int64 { return int64(p.Gid) }),
+	"egid":    procNumeric("effective group id", func(p Process) int64 { return int64(p.Egid) }),
+	"tracer":  procNumeric("pid of the tracing process, 0 if not traced", func(p Process) int64 { return int64(p.TracerPid) }),
+	"threads": procNumeric("number of threads", func(p Process) int64 { return p.Num_threads }),
+	"nice":    procNumeric("nice value", func(p Process) int64 { return p.Nice }),
+	"user":    procText("name of the real user", func(p Process) string { return p.User.Username }),
+	"comm":    procText("executable name", func(p Process) string { return p.Comm }),
+	"exe":     procText("path of the executable", func(p Process) string { return p.Exelink }),
+	"exesum":  procText("hash of the executable, see --hash", func(p Process) string { return p.Exesum }),
+	"cmdline": procText("command line with space separated arguments", func(p Process) string { return p.Args() }),
+	"state":   procText("process state, ex. S or Z", func(p Process) string { return string(p.State) }),
+	"exedel":  {kind: flagField, help: "true if the executable was deleted", flag: func(p Process) bool { return p.Exedel }},
+	"seccomp": procText("seccomp mode, ex. disabled or filter", func(p Process) string { return p.SeccompMode() }),
+	"runtime": procText("container runtime, ex. docker, empty outside of containers", func(p Process) string {
+		if p.Container == nil {
+			return ""
+		}
+		return p.Container.Runtime
+	}),
+	"container": procText("container id, empty outside of containers", func(p Process) string {
+		if p.Container == nil {
+			return ""
+		}
+		return p.Container.Id
+	}),
+	"nschanged":  {kind: flagField, help: "true if the pid, net or mnt namespace differs from the parent's", flag: func(p Process) bool { return len(p.NsChanged) > 0 }},
+	"cap":        {kind: textField, help: "any effective capability, ex. CAP_SYS_ADMIN", text: func(p Process) []string { return p.CapEff.Names() }},
+	"nonewprivs": {kind: flagField, help: "true if no_new_privs is set", flag: func(p Process) bool { return p.NoNewPrivs }},
+
+	"socket.port":  socketNumeric("local port of any socket", func(s Socket) int64 { return int64(s.Local_port) }),
+	"socket.rport": socketNumeric("remote port of any socket", func(s Socket) int64 { return int64(s.Remote_port) }),
+	"socket.addr":  socketText("local address of any socket", func(s Socket) string { return s.Local_addr }),
+	"socket.raddr": socketText("remote address of any socket", func(s Socket) string { return s.Remote_addr }),
+	"socket.proto": socketText("protocol of any socket, ex. tcp, udp6 or unix", func(s Socket) string { return s.ProtocolName() }),
+	"socket.state": socketText("state of any socket, ex. LISTEN", func(s Socket) string { return s.StateName() }),
+	"socket.path":  socketText("path of any unix socket", func(s Socket) string { return s.Path }),
+}
+
+// Describe the fields available in filters, for use in help text
+func FilterHelp() string {
+	names := make([]string, 0, len(FilterFields))
+	for n := range FilterFields {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	help := ""
+	for _, n := range names {
+		help += fmt.Sprintf("  %-13s %s\n", n, FilterFields[n].help)
+	}
+	return help
+}
+
+// Filter nodes
+
+type andFilter struct{ left, right Filter }
+type orFilter struct{ left, right Filter }
+type notFilter struct{ inner Filter }
+type flagFilter struct{ field filterField }
+
+type compareFilter struct {
+	field filterField
+	op    string
+	num   int64
+	text  string
+	re    *regexp.Regexp
+}
+
+func (f andFilter) Match(p Process) bool  { return f.left.Match(p) && f.right.Match(p) }
+func (f orFilter) Match(p Process) bool   { return f.left.Match(p) || f.right.Match(p) }
+func (f notFilter) Match(p Process) bool  { return !f.inner.Match(p) }
+func (f flagFilter) Match(p Process) bool { return f.field.flag(p) }
+
+func (f compareFilter) Match(p Process) bool {
+	switch f.field.kind {
+	case numericField:
+		for _, v := range f.field.num(p) {
+			if compare(v, f.num, f.op) {
+				return true
+			}
+		}
+	case textField:
+		for _, v := range f.field.text(p) {
+			if f.re != nil {
+				if f.re.MatchString(v) == (f.op == "=~") {
+					return true
+				}
+			} else if compare(v, f.text, f.op) {
+				return true
+			}
+		}
+	case flagField:
+		return compare(strconv.FormatBool(f.field.flag(p)), f.text, f.op)
+	}
+	return false
+}
+
+func compare[T int64 | string](a T, b T, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// Keep only the processes matched by f
+func FilterProcesses(procs map[int]Process, f Filter) map[int]Process {
+	matches := make(map[int]Process)
+	for pid, p := range procs {
+		if f.Match(p) {
+			matches[pid] = p
+		}
+	}
+	return matches
+}
+
+// Parsing
+
+type token struct {
+	kind  string // "word", "string", "op" or "paren"
+	value string
+	pos   int
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "=", "!"}
+
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{"paren", string(c), i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(expr[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{"string", expr[i+1 : i+1+end], i})
+			i += end + 2
+		default:
+			op := ""
+			for _, o := range filterOps {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op != "" {
+				tokens = append(tokens, token{"op", op, i})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(expr) && !unicode.IsSpace(rune(expr[i])) && !strings.ContainsRune("()\"'=!<>&|", rune(expr[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected '%c' at position %d", expr[i], i)
+			}
+			tokens = append(tokens, token{"word", expr[start:i], start})
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (fp *filterParser) peek() *token {
+	if fp.pos < len(fp.tokens) {
+		return &fp.tokens[fp.pos]
+	}
+	return nil
+}
+
+func (fp *filterParser) next() *token {
+	t := fp.peek()
+	if t != nil {
+		fp.pos++
+	}
+	return t
+}
+
+// True if the next token is one of the given keywords or operators
+func (fp *filterParser) accept(values ...string) bool {
+	t := fp.peek()
+	if t != nil && (t.kind == "word" || t.kind == "op") && slices.Contains(values, strings.ToLower(t.value)) {
+		fp.pos++
+		return true
+	}
+	return false
+}
+
+func (fp *filterParser) parseOr() (Filter, error) {
+	left, err := fp.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for fp.accept("or", "||") {
+		right, err := fp.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (fp *filterParser) parseAnd() (Filter, error) {
+	left, err := fp.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for fp.accept("and", "&&") {
+		right, err := fp.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (fp *filterParser) parseUnary() (Filter, error) {
+	if fp.accept("not", "!") {
+		inner, err := fp.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{inner}, nil
+	}
+
+	t := fp.next()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+	if t.kind == "paren" && t.value == "(" {
+		inner, err := fp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if end := fp.next(); end == nil || end.value != ")" {
+			return nil, fmt.Errorf("missing ')' for '(' at position %d", t.pos)
+		}
+		return inner, nil
+	}
+	if t.kind != "word" {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", t.value, t.pos)
+	}
+
+	field, exists := FilterFields[strings.ToLower(t.value)]
+	if !exists {
+		return nil, fmt.Errorf("unknown field '%s' at position %d", t.value, t.pos)
+	}
+
+	op := fp.peek()
+	if op == nil || op.kind != "op" || slices.Contains([]string{"&&", "||", "!"}, op.value) {
+		// Boolean fields can be used on their own
+		if field.kind == flagField {
+			return flagFilter{field}, nil
+		}
+		return nil, fmt.Errorf("expected a comparison after '%s' at position %d", t.value, t.pos)
+	}
+	fp.pos++
+
+	value := fp.next()
+	if value == nil || (value.kind != "word" && value.kind != "string") {
+		return nil, fmt.Errorf("expected a value after '%s' at position %d", op.value, op.pos)
+	}
+
+	return newCompareFilter(t.value, field, op.value, value.value)
+}
+
+func newCompareFilter(name string, field filterField, op string, value string) (Filter, error) {
+	if op == "=" {
+		op = "=="
+	}
+	cmp := compareFilter{field: field, op: op, text: value}
+
+	if op == "=~" || op == "!~" {
+		if field.kind != textField {
+			return nil, fmt.Errorf("'%s' can only be used with text fields, not '%s'", op, name)
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %s", value, err)
+		}
+		cmp.re = re
+		return cmp, nil
+	}
+
+	switch field.kind {
+	case numericField:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			// Allow durations for time based fields
+			d, derr := time.ParseDuration(value)
+			if derr != nil {
+				return nil, fmt.Errorf("expected a number for '%s', got '%s'", name, value)
+			}
+			n = int64(d.Seconds())
+		}
+		cmp.num = n
+	case flagField:
+		if value != "true" && value != "false" {
+			return nil, fmt.Errorf("expected true or false for '%s', got '%s'", name, value)
+		}
+		if op != "==" && op != "!=" {
+			return nil, fmt.Errorf("'%s' can't be used with '%s'", op, name)
+		}
+	}
+	return cmp, nil
+}
+
+// Parse a filter expression
+func ParseFilter(expr string) (Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %s", err)
+	}
+	fp := filterParser{tokens: tokens}
+	f, err := fp.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %s", err)
+	}
+	if t := fp.peek(); t != nil {
+		return nil, fmt.Errorf("invalid filter: unexpected '%s' at position %d", t.value, t.pos)
+	}
+	return f, nil
+}
@@ -0,0 +1,110 @@
+package proc
+
+import (
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"seer/pkg/users"
+)
+
+// Processes matched against by TestParseFilter
+func filterProcesses() map[int]Process {
+	now := time.Now()
+	return map[int]Process{
+		1: {
+			Pid: 1, Comm: "init", Exelink: "/sbin/init", User: users.User{Username: "root"},
+			Started: now.Add(-2 * time.Hour),
+			Sockets: []Socket{{Protocol: "tcp", Family: "inet", Local_addr: "0.0.0.0", Local_port: 22, State: LISTEN}},
+		},
+		42: {
+			Pid: 42, Comm: "my (evil) proc", Exelink: "/tmp/.x (deleted)", Exedel: true, Uid: 1000, User: users.User{Username: "alice"},
+			Started: now.Add(-5 * time.Minute),
+			Sockets: []Socket{{Protocol: "tcp", Family: "inet", Local_addr: "192.168.1.10", Local_port: 41000, Remote_addr: "203.0.113.7", Remote_port: 4444, State: ESTABLISHED}},
+		},
+		100: {
+			Pid: 100, Comm: "nc", Exelink: "/usr/bin/nc", Uid: 1000, User: users.User{Username: "alice"},
+			Started: now.Add(-30 * time.Minute),
+		},
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"uid == 0", []int{1}},
+		{"uid = 0", []int{1}},
+		{"uid >= 1000 and exedel", []int{42}},
+		{"user == alice AND NOT exedel", []int{100}},
+		// and binds tighter than or
+		{"comm == init or uid == 1000 and exedel", []int{1, 42}},
+		{"(comm == init or uid == 1000) and exedel", []int{42}},
+		{"comm == init || uid == 1000 && !exedel", []int{1, 100}},
+		{"not (uid == 0 or exedel)", []int{100}},
+		{"exedel", []int{42}},
+		{"exedel == false", []int{1, 100}},
+		{`comm == "my (evil) proc"`, []int{42}},
+		{`comm == 'my (evil) proc'`, []int{42}},
+		{`comm =~ "^(nc|ncat|socat)$"`, []int{100}},
+		{`exe !~ "^/tmp/"`, []int{1, 100}},
+		{"age < 10m", []int{42}},
+		{"age > 1h", []int{1}},
+		{"age >= 600", []int{1, 100}},
+		// Socket fields match if any socket matches, processes without sockets never do
+		{"socket.port == 22", []int{1}},
+		{"socket.port != 22", []int{42}},
+		{"socket.rport == 4444", []int{42}},
+		{"socket.state == LISTEN", []int{1}},
+	}
+	procs := filterProcesses()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int, 0)
+			for pid := range FilterProcesses(procs, f) {
+				got = append(got, pid)
+			}
+			sort.Ints(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got pids %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "invalid filter: unexpected end of filter"},
+		{"uid ==", "invalid filter: expected a value after '==' at position 4"},
+		{"comm", "invalid filter: expected a comparison after 'comm' at position 0"},
+		{"bogus == 1", "invalid filter: unknown field 'bogus' at position 0"},
+		{"(uid == 0", "invalid filter: missing ')' for '(' at position 0"},
+		{"uid == 0)", "invalid filter: unexpected ')' at position 8"},
+		{"uid == 0 uid", "invalid filter: unexpected 'uid' at position 9"},
+		{`comm == "init`, "invalid filter: unterminated string at position 8"},
+		{"uid =~ 0", "invalid filter: '=~' can only be used with text fields, not 'uid'"},
+		{"age < soon", "invalid filter: expected a number for 'age', got 'soon'"},
+		{"exedel == maybe", "invalid filter: expected true or false for 'exedel', got 'maybe'"},
+		{"exedel < true", "invalid filter: '<' can't be used with 'exedel'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseFilter(tt.expr)
+			if err == nil {
+				t.Fatalf("got no error, want %q", tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package proc
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"sync"
+	"syscall"
+
+	"seer/pkg/sysfs"
+)
+
+// Algorithms that can be used to hash executables
+var HashAlgorithms = []string{"md5", "sha1", "sha256"}
+
+// The algorithm used for Process.Exesum
+var HashAlgorithm = "md5"
+
+// Select the algorithm used to hash executables
+func SetHashAlgorithm(alg string) error {
+	if !slices.Contains(HashAlgorithms, alg) {
+		return fmt.Errorf("unknown hash algorithm '%s' (expected one of %v)", alg, HashAlgorithms)
+	}
+	HashAlgorithm = alg
+	return nil
+}
+
+func newHash(alg string) hash.Hash {
+	switch alg {
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	default:
+		return md5.New()
+	}
+}
+
+// Files are identified by device, inode and modification time so a file
+// replaced or modified in place is hashed again
+type hashKey struct {
+	dev   uint64
+	inode uint64
+	mtime int64
+	alg   string
+}
+
+var (
+	hashCache = make(map[hashKey]string)
+	hashLock  sync.Mutex
+)
+
+// Hash a file under the proc root with alg
+// Many processes share an executable so hashes are cached for the life of the program
+func hashFile(name string, alg string) (string, error) {
+	f, err := sysfs.Proc.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var key *hashKey
+	if info, err := f.Stat(); err == nil {
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			key = &hashKey{dev: uint64(st.Dev), inode: st.Ino, mtime: info.ModTime().UnixNano(), alg: alg}
+			hashLock.Lock()
+			sum, exists := hashCache[*key]
+			hashLock.Unlock()
+			if exists {
+				return sum, nil
+			}
+		}
+	}
+
+	h := newHash(alg)
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := fmt.Sprintf("%x", h.Sum(nil))
+	if key != nil {
+		hashLock.Lock()
+		hashCache[*key] = sum
+		hashLock.Unlock()
+	}
+	return sum, nil
+}
+
+// Hash the executable of the process with alg, regardless of HashAlgorithm
+func (p Process) ExeHash(alg string) (string, error) {
+	return hashFile(fmt.Sprintf("%d/exe", p.Pid), alg)
+}
@@ -0,0 +1,260 @@
+package proc
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seer/pkg/sysfs"
+)
+
+// Sources used to find processes when looking for hidden ones
+const (
+	SourceReaddir = "readdir" // The /proc directory listing
+	SourceStat    = "stat"    // Calling stat on every possible /proc/[pid]
+	SourceTask    = "task"    // The children files and thread group ids in /proc/[pid]/task
+	SourceParent  = "parent"  // The parent pids of known processes
+	SourceSocket  = "socket"  // The holders of sockets in /proc/net
+)
+
+const (
+	DefaultPidMax = 32768   // Used when pid_max can't be read
+	pidMaxLimit   = 4194304 // The largest pid_max allowed by the kernel
+)
+
+// A pid that was visible to some enumeration sources but not others
+type HiddenProcess struct {
+	Pid     int      `json:"pid"`
+	Comm    string   `json:"comm"`
+	Found   []string `json:"found_in"`
+	Missing []string `json:"missing_from"`
+	Detail  string   `json:"detail,omitempty"`
+}
+
+func (h HiddenProcess) String() string {
+	if h.Pid == 0 {
+		return fmt.Sprintf("WARNING: %s\n", h.Detail)
+	}
+	detail := ""
+	if h.Detail != "" {
+		detail = " " + h.Detail
+	}
+	return fmt.Sprintf("[%d] %s found in %s but not %s%s\n",
+		h.Pid, h.Comm, strings.Join(h.Found, ","), strings.Join(h.Missing, ","), detail,
+	)
+}
+
+func (h HiddenProcess) Columns() []string {
+	return []string{"PID", "COMM", "FOUND", "MISSING", "DETAIL"}
+}
+
+func (h HiddenProcess) Row() []string {
+	return []string{strconv.Itoa(h.Pid), h.Comm, strings.Join(h.Found, ","), strings.Join(h.Missing, ","), h.Detail}
+}
+
+// Get the largest pid from /proc/sys/kernel/pid_max
+func PidMax() int {
+	data, err := sysfs.Proc.ReadFile("sys/kernel/pid_max")
+	if err != nil {
+		slog.Debug("Failed to read pid_max", "error", err.Error())
+		return DefaultPidMax
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || max <= 0 || max > pidMaxLimit {
+		return DefaultPidMax
+	}
+	return max
+}
+
+// Get the pids in the /proc directory listing
+func listedPids() map[int]bool {
+	pids := make(map[int]bool)
+	contents, err := sysfs.Proc.ReadDir(".")
+	if err != nil {
+		slog.Warn("Failed to list processes", "error", err.Error())
+		return pids
+	}
+	for _, entry := range contents {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids[pid] = true
+		}
+	}
+	return pids
+}
+
+// Get the thread group id of a pid or thread id from /proc/[id]/status
+func readTgid(id int) (int, error) {
+	data, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/status", id))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, found := strings.CutPrefix(line, "Tgid:"); found {
+			return strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	return 0, fmt.Errorf("no Tgid in status of '%d'", id)
+}
+
+// Find pids that are missing from the /proc listing but visible through other sources
+// Every pid up to maxPid is checked with stat, which is what userland rootkits hooking readdir miss
+// In use inet sockets without any holder are also reported since their holder may be
+// hidden in a way none of the sources can see, unless the kernel holds them or a second
+// pass over the fds of every pid and thread finds their holder
+func FindHidden(maxPid int) []HiddenProcess {
+	listed := listedPids()
+	found := make(map[int][]string)
+	add := func(pid int, source string) {
+		if pid > 0 && !slices.Contains(found[pid], source) {
+			found[pid] = append(found[pid], source)
+		}
+	}
+	for pid := range listed {
+		add(pid, SourceReaddir)
+	}
+
+	// Stat finds thread ids as well, which are attributed to their thread group
+	for id := 1; id <= maxPid; id++ {
+		if _, err := sysfs.Proc.Stat(strconv.Itoa(id)); err != nil {
+			continue
+		}
+		tgid, err := readTgid(id)
+		if err != nil {
+			continue
+		}
+		if tgid == id {
+			add(id, SourceStat)
+		} else {
+			add(tgid, SourceTask)
+		}
+	}
+
+	for pid := range found {
+		// Parents of every known process
+		if stat, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/stat", pid)); err == nil {
+			fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+			if len(fields) > 1 {
+				ppid, _ := strconv.Atoi(fields[1])
+				add(ppid, SourceParent)
+			}
+		}
+		// Children of every thread, only available with CONFIG_PROC_CHILDREN
+		tasks, err := sysfs.Proc.ReadDir(fmt.Sprintf("%d/task", pid))
+		if err != nil {
+			continue
+		}
+		for _, t := range tasks {
+			children, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/task/%s/children", pid, t.Name()))
+			if err != nil {
+				continue
+			}
+			for _, c := range strings.Fields(string(children)) {
+				child, _ := strconv.Atoi(c)
+				add(child, SourceTask)
+			}
+		}
+	}
+
+	// Socket holders
+	index := SocketIndex{Owners: make(map[int][]SocketOwner)}
+	for pid := range found {
+		inodes, err := socketFds(pid)
+		if err != nil {
+			index.Unreadable += 1
+		}
+		for fd, inode := range inodes {
+			index.Owners[inode] = append(index.Owners[inode], SocketOwner{Pid: pid, Fd: fd})
+			add(pid, SourceSocket)
+		}
+	}
+
+	// Processes that started during the scan show up in the listing now
+	relisted := listedPids()
+	hidden := make([]HiddenProcess, 0)
+	for pid, sources := range found {
+		if listed[pid] || relisted[pid] {
+			continue
+		}
+		// Processes that exited during the scan are gone
+		if _, err := sysfs.Proc.Stat(strconv.Itoa(pid)); err != nil {
+			continue
+		}
+		h := HiddenProcess{Pid: pid, Found: sources, Missing: []string{SourceReaddir}}
+		if !slices.Contains(sources, SourceStat) {
+			h.Missing = append(h.Missing, SourceStat)
+		}
+		if p, err := getProcess(pid); err == nil {
+			h.Comm = p.Comm
+			h.Detail = fmt.Sprintf("%s (%s)", p.Exelink, p.Args())
+		}
+		slices.Sort(h.Found)
+		hidden = append(hidden, h)
+	}
+	slices.SortFunc(hidden, func(a, b HiddenProcess) int { return a.Pid - b.Pid })
+
+	// Without root the fds of other users' processes can't be read and every socket looks unowned
+	if os.Geteuid() != 0 && sysfs.LiveProc() {
+		slog.Warn("Not running as root, skipping unowned socket check")
+		return hidden
+	}
+	if index.Unreadable > 0 {
+		slog.Warn("Failed to read the fds of some processes, sockets they hold will look unowned", "processes", index.Unreadable)
+	}
+	sockets := GetSockets()
+	index.Annotate(sockets)
+	unowned := make([]Socket, 0)
+	for _, s := range sockets {
+		if s.Unowned == "" || s.Unowned == OwnerKernel || (s.Family != "inet" && s.Family != "inet6") {
+			continue
+		}
+		// Only sockets in use are held by a process, closed connections linger without one
+		if !s.Listening() && s.State != ESTABLISHED && s.State != CONNECTED {
+			continue
+		}
+		unowned = append(unowned, s)
+	}
+	if len(unowned) == 0 {
+		return hidden
+	}
+
+	// Sockets opened after the first pass have holders it missed, so the fds of every
+	// pid found by any source are read again along with those of each of their threads,
+	// which hold their own fd table after unshare(CLONE_FILES)
+	pids := relisted
+	for pid := range found {
+		pids[pid] = true
+	}
+	held := make(map[int]bool)
+	for pid := range pids {
+		ids := []int{pid}
+		if tasks, err := sysfs.Proc.ReadDir(fmt.Sprintf("%d/task", pid)); err == nil {
+			for _, t := range tasks {
+				if tid, err := strconv.Atoi(t.Name()); err == nil && tid != pid {
+					ids = append(ids, tid)
+				}
+			}
+		}
+		for _, id := range ids {
+			inodes, _ := socketFds(id)
+			for _, inode := range inodes {
+				held[inode] = true
+			}
+		}
+	}
+	for _, s := range unowned {
+		if held[s.Inode] {
+			continue
+		}
+		// The detail already says it's unowned
+		s.Unowned = ""
+		hidden = append(hidden, HiddenProcess{
+			Found:   []string{SourceSocket},
+			Missing: []string{SourceReaddir, SourceStat},
+			Detail:  "unowned socket " + strings.TrimSuffix(s.String(), "\n"),
+		})
+	}
+	return hidden
+}
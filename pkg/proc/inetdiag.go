@@ -0,0 +1,192 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"seer/pkg/sysfs"
+)
+
+// Where inet sockets are read from
+// netlink queries the kernel with sock_diag, which is faster on busy hosts and
+// includes tcp internals, proc parses the /proc/net text tables
+var SocketBackends = []string{"auto", "netlink", "proc"}
+
+// The backend used by GetSockets, auto uses netlink when it is available
+var SocketBackend = "auto"
+
+// Select the backend used to read inet sockets
+func SetSocketBackend(backend string) error {
+	if !slices.Contains(SocketBackends, backend) {
+		return fmt.Errorf("unknown socket backend '%s' (expected one of %v)", backend, SocketBackends)
+	}
+	SocketBackend = backend
+	return nil
+}
+
+// Attributes of struct inet_diag_msg responses
+// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/uapi/linux/inet_diag.h
+const (
+	inetDiagInfo     = 2  // INET_DIAG_INFO, struct tcp_info
+	inetDiagCong     = 4  // INET_DIAG_CONG, name of the congestion control algorithm
+	inetDiagCgroupId = 21 // INET_DIAG_CGROUP_ID, sent by default since linux 5.7
+)
+
+// Names of the tcp congestion states (tcpi_ca_state)
+var congestionStates = []string{"open", "disorder", "cwr", "recovery", "loss"}
+
+// Dump the inet sockets of a protocol, ext is a bitmask of extra attributes to include
+// (bit n-1 requests attribute n)
+func inetDiagDump(family uint8, protocol uint8, ext uint8) ([][]byte, error) {
+	request := inetDiagRequest(family, protocol, nil)
+	request[2] = ext
+	return sockDiag(sockDiagByFamily, request, syscall.NLM_F_DUMP)
+}
+
+// Decode an address from a struct inet_diag_sockid
+func diagAddr(family uint8, data []byte) string {
+	if family == syscall.AF_INET {
+		return netip.AddrFrom4([4]byte(data[:4])).String()
+	}
+	// v4-mapped ipv6 addresses keep their ::ffff: prefix, as in /proc/net
+	return netip.AddrFrom16([16]byte(data[:16])).String()
+}
+
+// Read the inet sockets of a protocol with sock_diag
+// The fields match readSockets, except that sockets have no references or
+// location, tm_when is in milliseconds rather than jiffies and sl is the position
+// in the dump
+func readDiagSockets(proto string, family string) ([]Socket, error) {
+	protocol, supported := diagProtocols[proto]
+	if !supported {
+		return nil, fmt.Errorf("sock_diag doesn't support %s sockets", proto)
+	}
+	af := uint8(syscall.AF_INET)
+	if family == "inet6" {
+		af = syscall.AF_INET6
+	}
+	msgs, err := inetDiagDump(af, protocol, 1<<(inetDiagInfo-1)|1<<(inetDiagCong-1))
+	if err != nil {
+		return nil, err
+	}
+
+	sockets := make([]Socket, 0, len(msgs))
+	for _, m := range msgs {
+		if len(m) < inetDiagMsgLength {
+			continue
+		}
+		// struct inet_diag_msg
+		socket := Socket{Protocol: proto, Family: family, Sl: len(sockets)}
+		socket.State = inetState(proto, int64(m[1]))
+		socket.Timer_active = int(m[2])
+		socket.Retrnsmt = int(m[3])
+		socket.Local_port = int(binary.BigEndian.Uint16(m[4:6]))
+		socket.Remote_port = int(binary.BigEndian.Uint16(m[6:8]))
+		socket.Local_addr = diagAddr(af, m[8:24])
+		socket.Remote_addr = diagAddr(af, m[24:40])
+		socket.Tm_when = int(binary.NativeEndian.Uint32(m[52:56]))
+		socket.Rx_queue = int(binary.NativeEndian.Uint32(m[56:60]))
+		socket.Tx_queue = int(binary.NativeEndian.Uint32(m[60:64]))
+		socket.Uid = int(binary.NativeEndian.Uint32(m[64:68]))
+		socket.Inode = int(binary.NativeEndian.Uint32(m[68:72]))
+
+		for _, attr := range parseDiagAttrs(m[inetDiagMsgLength:]) {
+			switch attr.Type {
+			case inetDiagInfo:
+				socket.parseTCPInfo(attr.Data)
+			case inetDiagCong:
+				socket.Congestion = strings.TrimRight(string(attr.Data), "\x00")
+			case inetDiagCgroupId:
+				if len(attr.Data) >= 8 {
+					socket.CgroupId = binary.NativeEndian.Uint64(attr.Data)
+				}
+			}
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, nil
+}
+
+// Read the fields of a struct tcp_info, older kernels send a shorter struct
+// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/uapi/linux/tcp.h
+func (s *Socket) parseTCPInfo(info []byte) {
+	if len(info) < 2 {
+		return
+	}
+	if int(info[1]) < len(congestionStates) && s.State != LISTEN {
+		s.CongState = congestionStates[info[1]]
+	}
+	if len(info) >= 76 {
+		// tcpi_rtt and tcpi_rttvar are in microseconds
+		s.Rtt = float64(binary.NativeEndian.Uint32(info[68:72])) / 1000
+		s.Rttvar = float64(binary.NativeEndian.Uint32(info[72:76])) / 1000
+	}
+	if len(info) >= 136 {
+		s.BytesReceived = binary.NativeEndian.Uint64(info[128:136])
+	}
+	if len(info) >= 208 {
+		s.BytesSent = binary.NativeEndian.Uint64(info[200:208])
+	}
+}
+
+// Read the inet sockets of a protocol with the selected backend
+func readInetSockets(proto string, family string, table string) []Socket {
+	_, supported := diagProtocols[proto]
+	// sock_diag reads the live kernel, not an alternate /proc
+	if SocketBackend == "proc" || !supported || !sysfs.LiveProc() {
+		return readSockets(proto, family, table)
+	}
+	sockets, err := readDiagSockets(proto, family)
+	if err != nil {
+		if SocketBackend == "netlink" {
+			slog.Warn("Failed to read sockets with sock_diag, reading /proc/net instead", "table", table, "error", err.Error())
+		} else {
+			slog.Debug("Failed to read sockets with sock_diag", "table", table, "error", err.Error())
+		}
+		return readSockets(proto, family, table)
+	}
+	return sockets
+}
+
+// CGROUP2_SUPER_MAGIC, the filesystem type of the cgroup v2 hierarchy
+const cgroup2Magic = 0x63677270
+
+// Paths of the cgroup v2 hierarchy keyed by cgroup id, which is the inode of the directory
+var cgroupPaths = sync.OnceValue(func() map[uint64]string {
+	paths := make(map[uint64]string)
+	// Hybrid systems mount cgroup v2 under the v1 hierarchies
+	for _, root := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		var stat syscall.Statfs_t
+		if syscall.Statfs(root, &stat) != nil || stat.Type != cgroup2Magic {
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			var stat syscall.Stat_t
+			if syscall.Stat(path, &stat) == nil {
+				paths[stat.Ino] = "/" + strings.TrimPrefix(strings.TrimPrefix(path, root), "/")
+			}
+			return nil
+		})
+		break
+	}
+	return paths
+})
+
+// Get the path of the cgroup the socket was created in, empty if unknown
+func (s Socket) CgroupPath() string {
+	if s.CgroupId == 0 || !sysfs.LiveProc() {
+		return ""
+	}
+	return cgroupPaths()[s.CgroupId]
+}
@@ -0,0 +1,179 @@
+package proc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"seer/pkg/sysfs"
+)
+
+// A memory mapping read from /proc/[pid]/maps
+type Mapping struct {
+	Start   uint64 `json:"start"`   // Start address
+	End     uint64 `json:"end"`     // End address (exclusive)
+	Perms   string `json:"perms"`   // Permissions, ex. r-xp
+	Offset  uint64 `json:"offset"`  // Offset into the mapped file
+	Dev     string `json:"dev"`     // Device of the mapped file as major:minor
+	Inode   uint64 `json:"inode"`   // Inode of the mapped file, 0 for anonymous mappings
+	Path    string `json:"path"`    // Mapped file or pseudo path such as [heap], empty for anonymous mappings
+	Deleted bool   `json:"deleted"` // True if the mapped file was deleted
+
+	Warnings []string `json:"warnings"` // Reasons the mapping looks like injected code
+}
+
+// Directories that libraries should never be loaded from
+var SuspiciousMapDirs = []string{"/tmp/", "/var/tmp/", "/dev/shm/", "/home/", "/root/", "/run/user/"}
+
+func (m Mapping) Executable() bool {
+	return strings.Contains(m.Perms, "x")
+}
+
+// True for mappings not backed by a file, including the heap, the stack
+// and shared anonymous memory which shows as a deleted /dev/zero
+func (m Mapping) Anonymous() bool {
+	if m.Path == "/dev/zero" && m.Deleted {
+		return true
+	}
+	return m.Inode == 0 && (m.Path == "" || strings.HasPrefix(m.Path, "["))
+}
+
+// Get the reasons a mapping looks like injected code
+// Only executable mappings are checked, data mappings of the same file would repeat each warning
+func (m Mapping) check() []string {
+	warnings := make([]string, 0)
+	if !m.Executable() {
+		return warnings
+	}
+	if m.Anonymous() {
+		if strings.HasPrefix(m.Perms, "rw") {
+			warnings = append(warnings, "anonymous rwx memory")
+		}
+		return warnings
+	}
+	if strings.HasPrefix(m.Path, "/memfd:") {
+		warnings = append(warnings, "executable memfd")
+	} else if m.Deleted {
+		warnings = append(warnings, "deleted file")
+	}
+	if !strings.HasPrefix(m.Path, "[") {
+		for _, dir := range SuspiciousMapDirs {
+			if strings.HasPrefix(m.Path, dir) {
+				warnings = append(warnings, "loaded from "+strings.TrimSuffix(dir, "/"))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+func (m Mapping) String() string {
+	path := m.Path
+	if m.Deleted {
+		path += " (deleted)"
+	}
+	warnings := ""
+	if len(m.Warnings) > 0 {
+		warnings = fmt.Sprintf(" WARNING: %s", strings.Join(m.Warnings, ", "))
+	}
+	return fmt.Sprintf("%012x-%012x %s %08x %s %d %s%s\n",
+		m.Start, m.End, m.Perms, m.Offset, m.Dev, m.Inode, path, warnings,
+	)
+}
+
+func (m Mapping) Columns() []string {
+	return []string{"START", "END", "PERMS", "OFFSET", "DEV", "INODE", "PATH", "WARNINGS"}
+}
+
+func (m Mapping) Row() []string {
+	path := m.Path
+	if m.Deleted {
+		path += " (deleted)"
+	}
+	return []string{
+		fmt.Sprintf("%x", m.Start),
+		fmt.Sprintf("%x", m.End),
+		m.Perms,
+		fmt.Sprintf("%x", m.Offset),
+		m.Dev,
+		strconv.FormatUint(m.Inode, 10),
+		path,
+		strings.Join(m.Warnings, ", "),
+	}
+}
+
+// Addresses and offsets are encoded as hex strings like they are in /proc
+func (m Mapping) MarshalJSON() ([]byte, error) {
+	type mapping Mapping
+	return json.Marshal(struct {
+		mapping
+		Start  string `json:"start"`
+		End    string `json:"end"`
+		Offset string `json:"offset"`
+	}{mapping(m), fmt.Sprintf("%x", m.Start), fmt.Sprintf("%x", m.End), fmt.Sprintf("%x", m.Offset)})
+}
+
+// Parse a line of /proc/[pid]/maps
+// ex. 55e67f4cb000-55e67f4d1000 r-xp 00002000 fe:00 681885    /usr/bin/head
+func parseMapping(line string) (Mapping, error) {
+	var m Mapping
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return m, fmt.Errorf("malformed mapping '%s'", line)
+	}
+	start, end, found := strings.Cut(fields[0], "-")
+	if !found {
+		return m, fmt.Errorf("malformed address range '%s'", fields[0])
+	}
+	var err error
+	if m.Start, err = strconv.ParseUint(start, 16, 64); err != nil {
+		return m, err
+	}
+	if m.End, err = strconv.ParseUint(end, 16, 64); err != nil {
+		return m, err
+	}
+	m.Perms = fields[1]
+	if m.Offset, err = strconv.ParseUint(fields[2], 16, 64); err != nil {
+		return m, err
+	}
+	m.Dev = fields[3]
+	if m.Inode, err = strconv.ParseUint(fields[4], 10, 64); err != nil {
+		return m, err
+	}
+	if len(fields) > 5 {
+		// Paths may contain spaces so take the rest of the line after the inode
+		rest := line
+		for i := 0; i < 5; i++ {
+			rest = strings.TrimLeft(rest, " ")
+			rest = rest[strings.IndexByte(rest, ' '):]
+		}
+		m.Path = strings.TrimSpace(rest)
+		if strings.HasSuffix(m.Path, " (deleted)") {
+			m.Path = strings.TrimSuffix(m.Path, " (deleted)")
+			m.Deleted = true
+		}
+	}
+	m.Warnings = m.check()
+	return m, nil
+}
+
+// Get the memory mappings of the process
+func (p Process) Maps() ([]Mapping, error) {
+	data, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/maps", p.Pid))
+	if err != nil {
+		return nil, err
+	}
+	maps := make([]Mapping, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		m, err := parseMapping(line)
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, m)
+	}
+	return maps, nil
+}
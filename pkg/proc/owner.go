@@ -0,0 +1,245 @@
+package proc
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"os"
+	"seer/pkg/sysfs"
+	"seer/pkg/users"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A process holding a socket through a file descriptor
+type SocketOwner struct {
+	Pid  int    `json:"pid"`
+	Fd   int    `json:"fd"`
+	Comm string `json:"comm"`
+	User string `json:"user"`
+}
+
+// Reasons a socket has no owner
+const (
+	OwnerKernel  = "kernel"     // Held by the kernel, ex. TIME_WAIT connections and the kernel side of netlink
+	OwnerMissing = "no process" // No visible process holds it, the holder may be hidden
+	OwnerUnknown = "unknown"    // Some fds couldn't be read (usually other users' without root), the holder may be among them
+)
+
+// The processes holding each socket, built from one pass over /proc/[pid]/fd
+type SocketIndex struct {
+	Owners     map[int][]SocketOwner // Keyed by socket inode, sorted by pid and fd
+	Unreadable int                   // Processes whose fds couldn't be read
+}
+
+// Get the socket inodes held by a pid keyed by fd
+func socketFds(pid int) (map[int]int, error) {
+	fds, err := Process{Pid: pid}.GetFds()
+	if err != nil {
+		return nil, err
+	}
+	inodes := make(map[int]int)
+	for fd, link := range fds {
+		if inode, found := strings.CutPrefix(link, "socket:["); found {
+			if i, err := strconv.Atoi(strings.TrimSuffix(inode, "]")); err == nil {
+				inodes[fd] = i
+			}
+		}
+	}
+	return inodes, nil
+}
+
+func (index *SocketIndex) add(pid int, comm string, user string) {
+	fds, err := socketFds(pid)
+	if err != nil {
+		slog.Debug("Failed to get process fds", "process", pid, "error", err.Error())
+		index.Unreadable += 1
+		return
+	}
+	for fd, inode := range fds {
+		index.Owners[inode] = append(index.Owners[inode], SocketOwner{Pid: pid, Fd: fd, Comm: comm, User: user})
+	}
+}
+
+func (index *SocketIndex) sort() {
+	for _, owners := range index.Owners {
+		slices.SortFunc(owners, func(a SocketOwner, b SocketOwner) int {
+			return cmp.Or(cmp.Compare(a.Pid, b.Pid), cmp.Compare(a.Fd, b.Fd))
+		})
+	}
+}
+
+// Index the sockets held by already read processes
+func IndexSockets(procs map[int]Process) SocketIndex {
+	index := SocketIndex{Owners: make(map[int][]SocketOwner)}
+	for _, p := range procs {
+		index.add(p.Pid, p.Comm, p.User.Username)
+	}
+	index.sort()
+	return index
+}
+
+// Index the sockets held by every process
+// Only the name and user of each process are read, which is much cheaper than GetProcesses
+func GetSocketIndex() SocketIndex {
+	index := SocketIndex{Owners: make(map[int][]SocketOwner)}
+	contents, err := sysfs.Proc.ReadDir(".")
+	if err != nil {
+		slog.Debug("Failed to list processes", "error", err.Error())
+		return index
+	}
+	names := make(map[int]string)
+	if users_map, err := users.GetUsers(); err == nil {
+		for _, u := range users_map {
+			names[u.Uid] = u.Username
+		}
+	}
+	for _, entry := range contents {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		comm, err := sysfs.Proc.ReadFile(entry.Name() + "/comm")
+		if err != nil {
+			// The process exited after it was listed
+			continue
+		}
+		user := ""
+		if status, err := sysfs.Proc.ReadFile(entry.Name() + "/status"); err == nil {
+			for _, line := range strings.Split(string(status), "\n") {
+				if value, found := strings.CutPrefix(line, "Uid:"); found {
+					if fields := strings.Fields(value); len(fields) > 0 {
+						uid, _ := strconv.Atoi(fields[0])
+						user = cmp.Or(names[uid], fields[0])
+					}
+					break
+				}
+			}
+		}
+		index.add(pid, strings.TrimSuffix(string(comm), "\n"), user)
+	}
+	index.sort()
+	return index
+}
+
+// True if every process' fds were read, so a socket missing from the index has no visible holder
+func (index SocketIndex) Complete() bool {
+	// Without root the fds of other users' processes can't be read
+	return index.Unreadable == 0 && (os.Geteuid() == 0 || !sysfs.LiveProc())
+}
+
+// Set the owners of each socket, sockets without owners get the reason
+func (index SocketIndex) Annotate(sockets []Socket) {
+	for i, s := range sockets {
+		sockets[i].Owners = index.Owners[s.Inode]
+		sockets[i].Unowned = ""
+		// Any process holding a packet socket can sniff the interfaces it is bound to
+		sockets[i].Capture = s.Family == "packet" && len(sockets[i].Owners) > 0
+		if len(sockets[i].Owners) > 0 {
+			continue
+		}
+		switch {
+		case s.Inode == 0 || (s.Family == "netlink" && s.Portid == 0):
+			sockets[i].Unowned = OwnerKernel
+		case index.Complete():
+			sockets[i].Unowned = OwnerMissing
+		default:
+			sockets[i].Unowned = OwnerUnknown
+		}
+	}
+}
+
+// The holders of the socket as pid/comm, netstat style, or the reason there are none
+// ("-" when unknown), empty for sockets that weren't annotated
+func (s Socket) OwnerName() string {
+	switch s.Unowned {
+	case "":
+	case OwnerUnknown:
+		return "-"
+	default:
+		return s.Unowned
+	}
+	names := make([]string, 0, len(s.Owners))
+	for _, o := range s.Owners {
+		name := fmt.Sprintf("%d/%s", o.Pid, o.Comm)
+		// Forked processes share the socket, list each of them once
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// The users of the processes holding the socket
+func (s Socket) OwnerUsers() string {
+	names := make([]string, 0, len(s.Owners))
+	for _, o := range s.Owners {
+		if !slices.Contains(names, o.User) {
+			names = append(names, o.User)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (s Socket) ownerPids() string {
+	pids := make([]string, 0, len(s.Owners))
+	for _, o := range s.Owners {
+		if pid := strconv.Itoa(o.Pid); !slices.Contains(pids, pid) {
+			pids = append(pids, pid)
+		}
+	}
+	if len(pids) == 0 && s.Unowned != "" {
+		return "-"
+	}
+	return strings.Join(pids, ",")
+}
+
+func (s Socket) ownerPrograms() string {
+	if s.Unowned != "" {
+		return s.OwnerName()
+	}
+	comms := make([]string, 0, len(s.Owners))
+	for _, o := range s.Owners {
+		if !slices.Contains(comms, o.Comm) {
+			comms = append(comms, o.Comm)
+		}
+	}
+	return strings.Join(comms, ",")
+}
+
+// The owners appended to String, empty for sockets that weren't annotated
+func (s Socket) ownerSuffix() string {
+	switch {
+	case len(s.Owners) > 0:
+		return fmt.Sprintf(" %s (%s)", s.OwnerName(), s.OwnerUsers())
+	case s.Unowned == OwnerMissing:
+		return " WARNING: " + s.OwnerName()
+	case s.Unowned != "":
+		return " " + s.OwnerName()
+	}
+	return ""
+}
+
+// A warning about the holders of the socket, empty if there is none
+func (s Socket) warning() string {
+	if s.Capture {
+		return "can capture traffic"
+	}
+	return ""
+}
+
+// The owner lines of Describe, empty for sockets that weren't annotated
+func (s Socket) describeOwners() string {
+	if s.Unowned != "" {
+		return fmt.Sprintf("├ Owner: %s\n", s.OwnerName())
+	}
+	desc := ""
+	for _, o := range s.Owners {
+		desc += fmt.Sprintf("├ Owner: [%d] %s %s fd:%d\n", o.Pid, o.Comm, o.User, o.Fd)
+	}
+	if warning := s.warning(); warning != "" {
+		desc += fmt.Sprintf("├ WARNING: the owners %s\n", warning)
+	}
+	return desc
+}
@@ -0,0 +1,179 @@
+package proc
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"seer/pkg/sysfs"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Ethernet protocol ids commonly bound by packet sockets
+// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/uapi/linux/if_ether.h
+var ethertypes = map[int]string{
+	0x0003: "ETH_P_ALL",
+	0x0800: "ETH_P_IP",
+	0x0806: "ETH_P_ARP",
+	0x8035: "ETH_P_RARP",
+	0x8100: "ETH_P_8021Q",
+	0x86DD: "ETH_P_IPV6",
+	0x888E: "ETH_P_PAE",
+	0x88CC: "ETH_P_LLDP",
+}
+
+// Netlink protocols
+// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/uapi/linux/netlink.h
+var netlinkProtocols = map[int]string{
+	0:  "route",
+	2:  "usersock",
+	3:  "firewall",
+	4:  "sock_diag",
+	5:  "nflog",
+	6:  "xfrm",
+	7:  "selinux",
+	8:  "iscsi",
+	9:  "audit",
+	10: "fib_lookup",
+	11: "connector",
+	12: "netfilter",
+	13: "ip6_fw",
+	14: "dnrtmsg",
+	15: "kobject_uevent",
+	16: "generic",
+	18: "scsitransport",
+	19: "ecryptfs",
+	20: "rdma",
+	21: "crypto",
+	22: "smc",
+}
+
+var packetTypes = map[int]string{
+	syscall.SOCK_RAW:   "raw",
+	syscall.SOCK_DGRAM: "dgram",
+}
+
+// Resolve an interface index to its name, 0 is used for sockets bound to every interface
+func ifaceName(index int) string {
+	if index == 0 {
+		return "any"
+	}
+	if !sysfs.LiveProc() {
+		// Interfaces of the running system don't apply to a snapshot
+		return strconv.Itoa(index)
+	}
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		slog.Debug("Failed to resolve interface", "index", index, "error", err.Error())
+		return strconv.Itoa(index)
+	}
+	return iface.Name
+}
+
+// Read the table at path (relative to /proc) skipping the header and splitting each line into fields
+func readTable(path string) (rows [][]string) {
+	contents, err := sysfs.Proc.ReadFile(path)
+	if err != nil {
+		slog.Debug("Failed to read file", "path", path, "error", err.Error())
+		return nil
+	}
+	for n, line := range strings.Split(string(contents), "\n") {
+		// Skip header and empty lines
+		if n == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+// Read the packet sockets in /proc/net/packet
+func readPacketSockets() (sockets []Socket) {
+	// Example:
+	// sk               RefCnt Type Proto  Iface R Rmem   User   Inode
+	// ffff8f0a45a3c000 3      3    0003   2     1 0      0      37571
+
+	for n, sock_data := range readTable("net/packet") {
+		if len(sock_data) < 9 {
+			continue
+		}
+
+		socket := Socket{}
+		socket.Protocol = "packet"
+		socket.Family = "packet"
+
+		socket.Sl = n
+		socket.Location, _ = strconv.ParseUint(sock_data[0], 16, 64)
+		socket.References, _ = strconv.Atoi(sock_data[1])
+		sock_type, _ := strconv.Atoi(sock_data[2])
+		socket.Type = packetTypes[sock_type]
+		ethertype, _ := strconv.ParseInt(sock_data[3], 16, 0)
+		socket.Subprotocol = ethertypes[int(ethertype)]
+		if socket.Subprotocol == "" {
+			socket.Subprotocol = fmt.Sprintf("0x%04x", ethertype)
+		}
+		socket.Ifindex, _ = strconv.Atoi(sock_data[4])
+		socket.Iface = ifaceName(socket.Ifindex)
+		socket.Rx_queue, _ = strconv.Atoi(sock_data[6])
+		socket.Uid, _ = strconv.Atoi(sock_data[7])
+		socket.Inode, _ = strconv.Atoi(sock_data[8])
+
+		sockets = append(sockets, socket)
+	}
+
+	return sockets
+}
+
+// Read the netlink sockets in /proc/net/netlink
+func readNetlinkSockets() (sockets []Socket) {
+	// Example:
+	// sk               Eth Pid        Groups   Rmem     Wmem     Dump  Locks    Drops    Inode
+	// ffff8f0a41a0e800 0   1234       00000551 0        0        0     2        0        20981
+
+	for n, sock_data := range readTable("net/netlink") {
+		if len(sock_data) < 10 {
+			continue
+		}
+
+		socket := Socket{}
+		socket.Protocol = "netlink"
+		socket.Family = "netlink"
+
+		socket.Sl = n
+		socket.Location, _ = strconv.ParseUint(sock_data[0], 16, 64)
+		protocol, _ := strconv.Atoi(sock_data[1])
+		socket.Subprotocol = netlinkProtocols[protocol]
+		if socket.Subprotocol == "" {
+			socket.Subprotocol = strconv.Itoa(protocol)
+		}
+		socket.Portid, _ = strconv.Atoi(sock_data[2])
+		groups, _ := strconv.ParseUint(sock_data[3], 16, 32)
+		socket.Groups = int(groups)
+		socket.Rx_queue, _ = strconv.Atoi(sock_data[4])
+		socket.Tx_queue, _ = strconv.Atoi(sock_data[5])
+		socket.Inode, _ = strconv.Atoi(sock_data[9])
+
+		sockets = append(sockets, socket)
+	}
+
+	return sockets
+}
+
+func (s Socket) packetString() string {
+	return fmt.Sprintf("<%d> packet %s %s %s i:%d\n",
+		s.Sl,
+		s.Type,
+		s.Subprotocol,
+		s.Iface,
+		s.Inode)
+}
+
+func (s Socket) netlinkString() string {
+	return fmt.Sprintf("<%d> netlink %s portid:%d groups:0x%x i:%d\n",
+		s.Sl,
+		s.Subprotocol,
+		s.Portid,
+		s.Groups,
+		s.Inode)
+}
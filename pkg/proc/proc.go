@@ -1,89 +1,124 @@
 package proc
 
 import (
-	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"log"
 	"log/slog"
-	"os"
+	"seer/pkg/sysfs"
 	"seer/pkg/users"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Process struct {
 	// stats read from /proc/<pid>/stat
-	Pid         int    // process id
-	Comm        string // executable name
-	State       rune   // process state
-	Ppid        int    // parent process id
-	Pgrp        int    // process group id
-	Session     int    // session id
-	Tty_nr      int    // controlling terminal
-	Tpgid       int    // id of process group controlling tty
-	Flags       uint   // kernel flags
-	Minflt      uint64 // number of minor faults
-	Cminflt     uint64 // number of minor faults by children
-	Majflt      uint64 // number of major faults
-	Cmajflt     uint64 // number of major faults by children
-	Utime       uint64 // clock ticks proc has been scheduled in user mode
-	Stime       uint64 // clock ticks proc has been scheduled in kernel mode
-	Cutime      int64  // clock ticks children have been scheduled in user mode
-	Cstime      int64  // clock ticks children have been scheduled in kernel mode
-	Priority    int64  // scheduling priority
-	Nice        int64  // the nice value
-	Num_threads int64  // number of threads in the proc
-	Itrealvalue int64  // jiffies before the next SIGALRM
-	Starttime   uint64 // clock ticks since boot at proc start
-	Vsize       uint64 // virtual memory size in bytes
+	Pid         int    `json:"pid"`         // process id
+	Comm        string `json:"comm"`        // executable name
+	State       rune   `json:"state"`       // process state
+	Ppid        int    `json:"ppid"`        // parent process id
+	Pgrp        int    `json:"pgrp"`        // process group id
+	Session     int    `json:"session"`     // session id
+	Tty_nr      int    `json:"tty_nr"`      // controlling terminal
+	Tpgid       int    `json:"tpgid"`       // id of process group controlling tty
+	Flags       uint   `json:"flags"`       // kernel flags
+	Minflt      uint64 `json:"minflt"`      // number of minor faults
+	Cminflt     uint64 `json:"cminflt"`     // number of minor faults by children
+	Majflt      uint64 `json:"majflt"`      // number of major faults
+	Cmajflt     uint64 `json:"cmajflt"`     // number of major faults by children
+	Utime       uint64 `json:"utime"`       // clock ticks proc has been scheduled in user mode
+	Stime       uint64 `json:"stime"`       // clock ticks proc has been scheduled in kernel mode
+	Cutime      int64  `json:"cutime"`      // clock ticks children have been scheduled in user mode
+	Cstime      int64  `json:"cstime"`      // clock ticks children have been scheduled in kernel mode
+	Priority    int64  `json:"priority"`    // scheduling priority
+	Nice        int64  `json:"nice"`        // the nice value
+	Num_threads int64  `json:"num_threads"` // number of threads in the proc
+	Itrealvalue int64  `json:"itrealvalue"` // jiffies before the next SIGALRM
+	Starttime   uint64 `json:"starttime"`   // clock ticks since boot at proc start
+	Vsize       uint64 `json:"vsize"`       // virtual memory size in bytes
+
+	Started time.Time `json:"started"` // when the process started, derived from Starttime and the boot time
 
 	// other stats
-	Exelink string // link to the executable
-	Exesum  string // md5sum of the executable in memory
-	Exedel  bool   // true if exe has been deleted from disk
-
-	Cmdline string // command line arguments
-
-	Uid  int // Real id of the user who started the process
-	Euid int // Effective user id
-	Suid int // Saved set user id
-	Fuid int // Filesystem user id
-
-	User users.User
-
-	Sockets []Socket // Sockets related to the process
+	Exelink    string `json:"exe"`         // link to the executable
+	Exesum     string `json:"exesum"`      // hash of the executable in memory
+	Exesum_alg string `json:"exesum_alg"`  // algorithm used for Exesum, ex. sha256
+	Exedel     bool   `json:"exe_deleted"` // true if exe has been deleted from disk
+
+	Cmdline string `json:"cmdline"` // command line arguments
+
+	Uid  int `json:"uid"`  // Real id of the user who started the process
+	Euid int `json:"euid"` // Effective user id
+	Suid int `json:"suid"` // Saved set user id
+	Fuid int `json:"fuid"` // Filesystem user id
+
+	// other fields read from /proc/<pid>/status
+	Gid        int          `json:"gid"`          // Real id of the group who started the process
+	Egid       int          `json:"egid"`         // Effective group id
+	Sgid       int          `json:"sgid"`         // Saved set group id
+	Fgid       int          `json:"fgid"`         // Filesystem group id
+	Groups     []int        `json:"groups"`       // Supplementary group ids
+	TracerPid  int          `json:"tracer_pid"`   // Pid of the process tracing this one, 0 if not traced
+	CapInh     Capabilities `json:"cap_inh"`      // Inheritable capabilities
+	CapPrm     Capabilities `json:"cap_prm"`      // Permitted capabilities
+	CapEff     Capabilities `json:"cap_eff"`      // Effective capabilities
+	CapBnd     Capabilities `json:"cap_bnd"`      // Capability bounding set
+	CapAmb     Capabilities `json:"cap_amb"`      // Ambient capabilities
+	NoNewPrivs bool         `json:"no_new_privs"` // True if execve can't grant privileges
+	Seccomp    int          `json:"seccomp"`      // Seccomp mode, see SeccompModes
+
+	User users.User `json:"user"`
+
+	Namespaces map[string]uint64 `json:"namespaces"`          // Namespace inodes by type, ex. net
+	NsChanged  []string          `json:"ns_changed"`          // Watched namespaces that differ from the parent's
+	Cgroups    []string          `json:"cgroups"`             // Distinct cgroup paths
+	Container  *Container        `json:"container,omitempty"` // The container the process runs in, if any
+
+	Sockets []Socket `json:"sockets"` // Sockets related to the process
+
+	Threads []Thread `json:"threads,omitempty"` // Only read when requested, see Process.GetThreads
+
+	Children []int `json:"children"`
+}
 
-	Children []int
+// Get the seconds since boot from /proc/uptime
+func readUptime() (float64, error) {
+	raw_uptime, err := sysfs.Proc.ReadFile("uptime")
+	if err != nil {
+		return 0, err
+	}
+	uptime, _, _ := strings.Cut(strings.TrimSpace(string(raw_uptime)), " ")
+	return strconv.ParseFloat(uptime, 64)
 }
 
 // Get the approximate process age in seconds
 func (p Process) Age() int {
-	raw_uptime, err := os.ReadFile("/proc/uptime")
-	if err != nil {
-		slog.Debug("Failed to read /proc/uptime", "error", err.Error())
-		return -1
+	if !p.Started.IsZero() {
+		return int(time.Since(p.Started).Seconds())
 	}
-	uptime, err := strconv.Atoi(strings.Split(string(raw_uptime), ".")[0])
+	// The boot time is unknown, fall back to the uptime
+	uptime, err := readUptime()
 	if err != nil {
-		slog.Debug("Failed to convert uptime to int", "error", err.Error())
+		slog.Debug("Failed to read /proc/uptime", "error", err.Error())
 		return -1
 	}
-
-	return uptime - (int(p.Starttime) / 100)
+	return int(uptime - ticksToSeconds(p.Starttime))
 }
 
 func (p Process) GetFds() (fds map[int]string, err error) {
 	fds = make(map[int]string)
-	fd_path := fmt.Sprintf("/proc/%d/fd/", p.Pid)
-	contents, err := os.ReadDir(fd_path)
+	fd_path := fmt.Sprintf("%d/fd", p.Pid)
+	contents, err := sysfs.Proc.ReadDir(fd_path)
 	if err != nil {
 		return nil, err
 	}
 	for _, entry := range contents {
-		link_path := fmt.Sprintf("%s%s", fd_path, entry.Name())
-		link, err := os.Readlink(link_path)
+		link_path := fmt.Sprintf("%s/%s", fd_path, entry.Name())
+		link, err := sysfs.Proc.ReadLink(link_path)
 		if err != nil {
 			continue
 		}
@@ -103,8 +138,8 @@ func (p Process) String() string {
 	if p.Exelink == "" {
 		exe = "kernel"
 	}
-	return fmt.Sprintf("[%d] %s (%s) %s %ds\n",
-		p.Pid, exe, program, p.User.Username, p.Age(),
+	return fmt.Sprintf("[%d] %s (%s) %s %ds started %s\n",
+		p.Pid, exe, program, p.User.Username, p.Age(), FormatStarted(p.Started),
 	)
 }
 
@@ -112,11 +147,39 @@ func (p Process) Describe() string {
 	desc := "┌[%d] %s\n"
 	desc += "├ comm: %s\n"
 	desc += "├ cmdline: %s\n"
-	desc += "├ state: %c age: %ds\n"
+	desc += "├ state: %c age: %ds started: %s\n"
 	desc += "├ parent: %d\n"
 	desc += "├ user: %s euid: %d\n"
+	desc += "├ gid: %d egid: %d groups: %v\n"
+	desc += "├ caps effective: %s\n"
+	desc += "├ caps permitted: %s\n"
+	desc += "├ caps inheritable: %s ambient: %s\n"
+	desc += "├ caps bounding: %s\n"
+	desc += "├ no new privs: %t seccomp: %s\n"
+	desc += "├ tracer: %d\n"
+	desc += "├ container: %s cgroups: %s\n"
+	desc += "├ namespaces: %s\n"
 	desc += "├ exe deleted: %t\n"
-	desc += "└ md5: %s\n"
+	desc += "└ %s: %s\n"
+
+	alg := p.Exesum_alg
+	if alg == "" {
+		alg = "hash"
+	}
+	container := "none"
+	if p.Container != nil {
+		container = p.Container.Runtime + " " + p.Container.Id
+	}
+	namespaces := ""
+	for _, ns := range NamespaceTypes {
+		if inode, exists := p.Namespaces[ns]; exists {
+			namespaces += fmt.Sprintf("%s:[%d] ", ns, inode)
+		}
+	}
+	namespaces = strings.TrimSpace(namespaces)
+	if len(p.NsChanged) > 0 {
+		namespaces += fmt.Sprintf(" (WARNING: not in the parent's %s namespace)", strings.Join(p.NsChanged, ","))
+	}
 
 	return fmt.Sprintf(desc,
 		p.Pid,
@@ -125,14 +188,90 @@ func (p Process) Describe() string {
 		p.Cmdline,
 		p.State,
 		p.Age(),
+		FormatStarted(p.Started),
 		p.Ppid,
 		p.User.Username,
 		p.Euid,
+		p.Gid,
+		p.Egid,
+		p.Groups,
+		p.CapEff,
+		p.CapPrm,
+		p.CapInh,
+		p.CapAmb,
+		p.CapBnd,
+		p.NoNewPrivs,
+		p.SeccompMode(),
+		p.TracerPid,
+		container,
+		strings.Join(p.Cgroups, ","),
+		namespaces,
 		p.Exedel,
+		alg,
 		p.Exesum,
 	)
 }
 
+// Format a start time in local time, empty if it is unknown
+func FormatStarted(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// The command line with arguments separated by spaces instead of null bytes
+func (p Process) Args() string {
+	return strings.ReplaceAll(strings.TrimRight(p.Cmdline, "\x00"), "\x00", " ")
+}
+
+func (p Process) Columns() []string {
+	return []string{"PID", "PPID", "USER", "STATE", "AGE", "STARTED", "EXE", "CMDLINE"}
+}
+
+func (p Process) Row() []string {
+	return []string{
+		strconv.Itoa(p.Pid),
+		strconv.Itoa(p.Ppid),
+		p.User.Username,
+		string(p.State),
+		fmt.Sprintf("%ds", p.Age()),
+		FormatStarted(p.Started),
+		p.Exelink,
+		p.Args(),
+	}
+}
+
+// Processes are encoded with the state as a string and the owning user
+// by name only, the full user record is available from `seer user`
+func (p Process) MarshalJSON() ([]byte, error) {
+	type process Process
+	return json.Marshal(struct {
+		process
+		State   string `json:"state"`
+		Cmdline string `json:"cmdline"`
+		User    string `json:"user"`
+		Age     int    `json:"age"`
+	}{process(p), string(p.State), p.Args(), p.User.Username, p.Age()})
+}
+
+func (p *Process) UnmarshalJSON(data []byte) error {
+	type process Process
+	aux := struct {
+		*process
+		State string `json:"state"`
+		User  string `json:"user"`
+	}{process: (*process)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.State != "" {
+		p.State = []rune(aux.State)[0]
+	}
+	p.User = users.User{Username: aux.User, Uid: p.Uid}
+	return nil
+}
+
 func (p Process) GetParents(procs map[int]Process) (parents []Process) {
 	if p.Ppid == 0 {
 		return
@@ -145,16 +284,20 @@ func (p Process) GetParents(procs map[int]Process) (parents []Process) {
 
 func getProcess(pid int) (Process, error) {
 	proc := Process{Pid: pid}
-	procDir := fmt.Sprintf("/proc/%d", pid)
+	procDir := strconv.Itoa(pid)
 
-	if _, e := os.Stat(procDir); os.IsNotExist(e) {
+	if _, e := sysfs.Proc.Stat(procDir); errors.Is(e, fs.ErrNotExist) {
 		return proc, fmt.Errorf("the process '%d' does not exist", pid)
 	}
 
 	// Read data from /proc/[pid]/stat
 
 	statFile := procDir + "/stat"
-	statData, _ := os.ReadFile(statFile)
+	statData, err := sysfs.Proc.ReadFile(statFile)
+	if err != nil || len(statData) == 0 {
+		// The process exited while it was being read
+		return proc, fmt.Errorf("the process '%d' does not exist", pid)
+	}
 	statStr := string(statData)
 
 	// Read comm then slice past it
@@ -190,52 +333,97 @@ func getProcess(pid int) (Process, error) {
 		&proc.Itrealvalue,
 		&proc.Starttime,
 		&proc.Vsize)
+	proc.Started = startedAt(proc.Starttime)
 
 	// Read /proc/[pid]/exe (often requires root)
 
 	exeFile := procDir + "/exe"
 
-	linkData, _ := os.Readlink(exeFile)
+	linkData, _ := sysfs.Proc.ReadLink(exeFile)
 	proc.Exelink = linkData
 
 	proc.Exedel = strings.Contains(linkData, "(deleted)")
 
-	// Get the md5sum of the in memory executable
-	exeData, _ := os.Open(exeFile)
-
-	h := md5.New()
-	if _, e := io.Copy(h, exeData); e == nil {
-		sum := fmt.Sprintf("%x", h.Sum(nil))
+	// Get the hash of the in memory executable
+	if sum, err := hashFile(exeFile, HashAlgorithm); err == nil {
 		proc.Exesum = sum
+		proc.Exesum_alg = HashAlgorithm
 	}
 
 	// Read /proc/[pid]/cmdline
 
 	cmdFile := procDir + "/cmdline"
-	cmdData, _ := os.ReadFile(cmdFile)
+	cmdData, _ := sysfs.Proc.ReadFile(cmdFile)
 	proc.Cmdline = string(cmdData)
 
 	// Read UID info from /proc/[pid]/status
 
 	statusFile := procDir + "/status"
-	statusData, _ := os.ReadFile(statusFile)
-	statusStr := string(statusData)
-	uidStart := strings.Index(statusStr, "Uid:")
-	uidEnd := strings.IndexRune(statusStr[uidStart:], '\n')
-	fmt.Sscanf(
-		statusStr[uidStart+4:uidStart+uidEnd],
-		"%d %d %d %d",
-		&proc.Uid,
-		&proc.Euid,
-		&proc.Suid,
-		&proc.Fuid)
+	statusData, _ := sysfs.Proc.ReadFile(statusFile)
+	proc.parseStatus(string(statusData))
+
+	// Read namespaces and cgroups
+
+	proc.Namespaces = readNamespaces(pid)
+	proc.Cgroups = readCgroups(pid)
+	proc.Container = findContainer(proc.Cgroups)
 
 	return proc, nil
 }
 
+// Seccomp modes by the value of the Seccomp line in /proc/[pid]/status
+var SeccompModes = []string{"disabled", "strict", "filter"}
+
+// Fill in the fields read from /proc/[pid]/status
+func (p *Process) parseStatus(status string) {
+	for _, line := range strings.Split(status, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Uid":
+			fmt.Sscanf(value, "%d %d %d %d", &p.Uid, &p.Euid, &p.Suid, &p.Fuid)
+		case "Gid":
+			fmt.Sscanf(value, "%d %d %d %d", &p.Gid, &p.Egid, &p.Sgid, &p.Fgid)
+		case "Groups":
+			for _, g := range strings.Fields(value) {
+				if id, err := strconv.Atoi(g); err == nil {
+					p.Groups = append(p.Groups, id)
+				}
+			}
+		case "TracerPid":
+			p.TracerPid, _ = strconv.Atoi(value)
+		case "CapInh":
+			p.CapInh = parseCapabilities(value)
+		case "CapPrm":
+			p.CapPrm = parseCapabilities(value)
+		case "CapEff":
+			p.CapEff = parseCapabilities(value)
+		case "CapBnd":
+			p.CapBnd = parseCapabilities(value)
+		case "CapAmb":
+			p.CapAmb = parseCapabilities(value)
+		case "NoNewPrivs":
+			p.NoNewPrivs = value == "1"
+		case "Seccomp":
+			p.Seccomp, _ = strconv.Atoi(value)
+		}
+	}
+}
+
+// Get the name of the process' seccomp mode
+func (p Process) SeccompMode() string {
+	if p.Seccomp >= 0 && p.Seccomp < len(SeccompModes) {
+		return SeccompModes[p.Seccomp]
+	}
+	return strconv.Itoa(p.Seccomp)
+}
+
 func GetProcesses() map[int]Process {
 	procs := make(map[int]Process)
-	contents, e := os.ReadDir("/proc")
+	contents, e := sysfs.Proc.ReadDir(".")
 	if e != nil {
 		log.Print(e.Error())
 	}
@@ -246,17 +434,19 @@ func GetProcesses() map[int]Process {
 		}
 
 		id, _ := strconv.Atoi(ename)
-		p, _ := getProcess(id)
+		p, err := getProcess(id)
+		if err != nil {
+			continue
+		}
 		procs[id] = p
 	}
 
 	users, _ := users.GetUsers()
-	socks := GetSockets()
 
 	// Go back through the procs and add extra info
 	// Add child pids
+	// Compare namespaces with the parent
 	// Resolve user ids to users
-	// Add sockets to procs
 	for i, p := range procs {
 		for _, c := range procs {
 			if p.Pid == c.Ppid {
@@ -264,30 +454,27 @@ func GetProcesses() map[int]Process {
 			}
 		}
 		sort.Slice(p.Children, func(i, j int) bool { return p.Children[i] < p.Children[j] })
+		if parent, exists := procs[p.Ppid]; exists {
+			p.NsChanged = p.namespaceChanges(parent)
+		}
 		for _, u := range users {
 			if p.Uid == u.Uid {
 				p.User = u
 				break
 			}
 		}
-		fds, err := p.GetFds()
-		if err != nil {
-			slog.Debug("Failed to get process fds", "process", p.Pid, "error", err.Error())
-		} else {
-			for _, fd := range fds {
-				if strings.HasPrefix(fd, "socket:") {
-					inode := strings.Split(fd, "socket:[")[1]
-					inode = inode[:len(inode)-1]
-					for _, s := range socks {
-						if fmt.Sprintf("%d", s.Inode) == inode {
-							p.Sockets = append(p.Sockets, s)
-						}
-					}
-				}
-			}
-		}
 		procs[i] = p
 	}
 
+	// Add sockets to procs, once for each fd holding them
+	index := IndexSockets(procs)
+	for _, s := range GetSockets() {
+		for _, o := range index.Owners[s.Inode] {
+			p := procs[o.Pid]
+			p.Sockets = append(p.Sockets, s)
+			procs[o.Pid] = p
+		}
+	}
+
 	return procs
 }
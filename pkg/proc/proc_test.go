@@ -0,0 +1,101 @@
+package proc
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"seer/pkg/sysfs"
+)
+
+// The parsers read the fixture trees under testdata, which hold a system booted
+// at 2024-05-01 13:00:00 UTC with an init process and a suspicious child
+func TestMain(m *testing.M) {
+	sysfs.Proc = sysfs.Dir("testdata/proc")
+	sysfs.Etc = sysfs.Dir("testdata/etc")
+	os.Exit(m.Run())
+}
+
+func TestGetProcess(t *testing.T) {
+	boot := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		pid  int
+		want Process
+	}{
+		{1, Process{
+			Pid: 1, Comm: "init", State: 'S', Ppid: 0, Pgrp: 1, Session: 1, Tty_nr: 0, Tpgid: -1,
+			Flags: 4194560, Minflt: 52000, Cminflt: 1200000, Majflt: 90, Cmajflt: 400,
+			Utime: 150, Stime: 220, Cutime: 9000, Cstime: 4000, Priority: 20, Nice: 0,
+			Num_threads: 1, Starttime: 12, Vsize: 171814912,
+			Started: boot.Add(120 * time.Millisecond),
+			Exelink: "/sbin/init", Cmdline: "/sbin/init\x00splash\x00",
+			CapPrm: 0x1ffffffffff, CapEff: 0x1ffffffffff, CapBnd: 0x1ffffffffff,
+		}},
+		{42, Process{
+			// comm may contain spaces and parentheses
+			Pid: 42, Comm: "my (evil) proc", State: 'R', Ppid: 1, Pgrp: 42, Session: 42, Tty_nr: 34816, Tpgid: 42,
+			Flags: 4194304, Minflt: 800, Majflt: 2, Utime: 35, Stime: 12, Priority: 20,
+			Num_threads: 3, Starttime: 360050, Vsize: 12345678,
+			Started: boot.Add(3600500 * time.Millisecond),
+			Exelink: "/tmp/.x (deleted)", Exedel: true, Cmdline: "./.x\x00-c\x00/tmp/c2\x00",
+			Uid: 1000, Euid: 0, Suid: 1000, Fuid: 1000, Gid: 1000, Egid: 1000, Sgid: 1000, Fgid: 1000,
+			Groups: []int{27, 1000}, TracerPid: 7,
+			CapPrm: 0x3000, CapEff: 0x3000, CapBnd: 0x1ffffffffff, CapAmb: 0x1000,
+			NoNewPrivs: true, Seccomp: 2,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want.Comm, func(t *testing.T) {
+			got, err := getProcess(tt.pid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Only compare the fields read from stat, status, cmdline and exe
+			got.Exesum, got.Exesum_alg = "", ""
+			got.Namespaces, got.Cgroups, got.Container = nil, nil, nil
+			if !got.Started.Equal(tt.want.Started) {
+				t.Errorf("got started %s, want %s", got.Started, tt.want.Started)
+			}
+			got.Started = tt.want.Started
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetProcessMissing(t *testing.T) {
+	if _, err := getProcess(7); err == nil {
+		t.Error("got no error for a pid without a /proc entry")
+	}
+}
+
+func TestGetProcesses(t *testing.T) {
+	procs := GetProcesses()
+	if len(procs) != 2 {
+		t.Fatalf("got %d processes, want 2", len(procs))
+	}
+	tests := []struct {
+		pid      int
+		user     string
+		children []int
+		args     string
+	}{
+		{1, "root", []int{42}, "/sbin/init splash"},
+		{42, "alice", nil, "./.x -c /tmp/c2"},
+	}
+	for _, tt := range tests {
+		p := procs[tt.pid]
+		if p.User.Username != tt.user {
+			t.Errorf("pid %d: got user %q, want %q", tt.pid, p.User.Username, tt.user)
+		}
+		if !slices.Equal(p.Children, tt.children) {
+			t.Errorf("pid %d: got children %v, want %v", tt.pid, p.Children, tt.children)
+		}
+		if p.Args() != tt.args {
+			t.Errorf("pid %d: got args %q, want %q", tt.pid, p.Args(), tt.args)
+		}
+	}
+}
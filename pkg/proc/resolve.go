@@ -0,0 +1,197 @@
+package proc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/netip"
+	"seer/pkg/sysfs"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How socket endpoints are named in text output
+type Resolver struct {
+	Numeric bool          // Print addresses and ports as numbers
+	DNS     bool          // Look up addresses without a hosts entry with reverse DNS
+	Timeout time.Duration // Time allowed for each reverse DNS lookup
+}
+
+// Time allowed for each reverse DNS lookup when none is set
+const DefaultDNSTimeout = time.Second
+
+// Endpoints are numeric unless a command enables names, so other output
+// (ex. audit findings) is unaffected
+var resolver = Resolver{Numeric: true}
+
+// Set how socket endpoints are named
+func SetResolver(r Resolver) {
+	if r.Timeout <= 0 {
+		r.Timeout = DefaultDNSTimeout
+	}
+	resolver = r
+}
+
+// Read a file from the etc root and split each line into fields, skipping comments
+func readEtcTable(name string) [][]string {
+	data, err := sysfs.Etc.ReadFile(name)
+	if err != nil {
+		slog.Debug("Failed to read file", "path", name, "error", err.Error())
+		return nil
+	}
+	rows := make([][]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows = append(rows, fields)
+		}
+	}
+	return rows
+}
+
+// Service names from /etc/services keyed by port/protocol, ex. 22/tcp
+var services = sync.OnceValue(func() map[string]string {
+	names := make(map[string]string)
+	for _, fields := range readEtcTable("services") {
+		if len(fields) < 2 {
+			continue
+		}
+		// The first name listed for a port wins
+		if _, exists := names[fields[1]]; !exists {
+			names[fields[1]] = fields[0]
+		}
+	}
+	return names
+})
+
+// Host names from /etc/hosts keyed by address
+var hosts = sync.OnceValue(func() map[netip.Addr]string {
+	names := make(map[netip.Addr]string)
+	for _, fields := range readEtcTable("hosts") {
+		addr, err := netip.ParseAddr(fields[0])
+		if err != nil || len(fields) < 2 {
+			continue
+		}
+		// The first name listed for an address is its canonical name
+		if _, exists := names[addr.Unmap()]; !exists {
+			names[addr.Unmap()] = fields[1]
+		}
+	}
+	return names
+})
+
+// Reverse DNS results keyed by address, failed lookups are cached as ""
+var (
+	dnsCache = make(map[netip.Addr]string)
+	dnsLock  sync.Mutex
+)
+
+// Look up the name of an address with reverse DNS
+func lookupDNS(addr netip.Addr) string {
+	dnsLock.Lock()
+	name, exists := dnsCache[addr]
+	dnsLock.Unlock()
+	if exists {
+		return name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolver.Timeout)
+	defer cancel()
+	names, err := net.DefaultResolver.LookupAddr(ctx, addr.String())
+	if err != nil {
+		slog.Debug("Failed reverse DNS lookup", "address", addr.String(), "error", err.Error())
+	} else if len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+
+	dnsLock.Lock()
+	dnsCache[addr] = name
+	dnsLock.Unlock()
+	return name
+}
+
+// Resolve the addresses of sockets with reverse DNS ahead of printing them,
+// lookups run concurrently so slow servers only delay output once
+func PrefetchNames(sockets []Socket) {
+	if resolver.Numeric || !resolver.DNS {
+		return
+	}
+	// Limit the lookups in flight
+	slots := make(chan struct{}, 16)
+	var wg sync.WaitGroup
+	for _, s := range sockets {
+		for _, a := range []string{s.Local_addr, s.Remote_addr} {
+			addr, err := netip.ParseAddr(a)
+			if err != nil || addr.IsUnspecified() {
+				continue
+			}
+			wg.Add(1)
+			slots <- struct{}{}
+			go func() {
+				defer wg.Done()
+				lookupDNS(addr.Unmap())
+				<-slots
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// The name of an address, * for the wildcard address
+func hostName(a string) string {
+	addr, err := netip.ParseAddr(a)
+	if resolver.Numeric || err != nil {
+		return a
+	}
+	if addr.IsUnspecified() {
+		return "*"
+	}
+	if name, exists := hosts()[addr.Unmap()]; exists {
+		return name
+	}
+	if resolver.DNS {
+		if name := lookupDNS(addr.Unmap()); name != "" {
+			return name
+		}
+	}
+	return a
+}
+
+// The service name of a port, * for no port
+func portName(protocol string, port int) string {
+	if resolver.Numeric {
+		return strconv.Itoa(port)
+	}
+	if port == 0 {
+		return "*"
+	}
+	switch protocol {
+	case "tcp":
+	case "udp", "udplite":
+		protocol = "udp"
+	default:
+		return strconv.Itoa(port)
+	}
+	if name, exists := services()[strconv.Itoa(port)+"/"+protocol]; exists {
+		return name
+	}
+	return strconv.Itoa(port)
+}
+
+// The local endpoint with host and service names, see SetResolver
+func (s Socket) LocalName() string {
+	if s.Family != "inet" && s.Family != "inet6" {
+		return s.LocalEndpoint()
+	}
+	return net.JoinHostPort(hostName(s.Local_addr), portName(s.Protocol, s.Local_port))
+}
+
+// The remote endpoint with host and service names, see SetResolver
+func (s Socket) RemoteName() string {
+	if s.Family != "inet" && s.Family != "inet6" {
+		return s.RemoteEndpoint()
+	}
+	return net.JoinHostPort(hostName(s.Remote_addr), portName(s.Protocol, s.Remote_port))
+}
@@ -0,0 +1,85 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"seer/pkg/sysfs"
+)
+
+// Signals that can be chosen by name
+var Signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+	"CONT": syscall.SIGCONT,
+	"STOP": syscall.SIGSTOP,
+}
+
+// Get a signal from a name such as TERM or SIGTERM or a number
+func ParseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n <= 0 || n > 64 {
+			return 0, fmt.Errorf("invalid signal number %d", n)
+		}
+		return syscall.Signal(n), nil
+	}
+	sig, exists := Signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !exists {
+		names := make([]string, 0, len(Signals))
+		for n := range Signals {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("unknown signal '%s' (expected a number or one of %v)", name, names)
+	}
+	return sig, nil
+}
+
+// Get the name of a signal such as SIGTERM
+func SignalName(sig syscall.Signal) string {
+	for name, s := range Signals {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
+
+// Send a signal to the process
+// The start time is checked first so a pid reused by another process is never signalled
+func (p Process) Signal(sig syscall.Signal) error {
+	if !sysfs.LiveProc() {
+		return errors.New("refusing to signal processes read from an alternate /proc")
+	}
+	starttime, err := readStarttime(p.Pid)
+	if err != nil || starttime != p.Starttime {
+		return fmt.Errorf("the process '%d' no longer exists", p.Pid)
+	}
+	if err := syscall.Kill(p.Pid, sig); err != nil {
+		return fmt.Errorf("failed to send %s to process '%d': %s", SignalName(sig), p.Pid, err)
+	}
+	return nil
+}
+
+// Read only the start time from /proc/[pid]/stat
+func readStarttime(pid int) (uint64, error) {
+	data, err := sysfs.Proc.ReadFile(strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	// Skip past comm, the start time is the 20th field after it
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat for process '%d'", pid)
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
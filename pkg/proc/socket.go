@@ -1,11 +1,16 @@
 package proc
 
 import (
+	"cmp"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"log/slog"
-	"os"
+	"net"
+	"net/netip"
+	"seer/pkg/sysfs"
+	"seer/pkg/utils"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -30,169 +35,393 @@ const (
 	NEW_SYN_RECV
 )
 
+// States of udp, raw and unix sockets, which don't have tcp states
+// The kernel reports connectionless sockets as ESTABLISHED or CLOSE and unix
+// sockets with their own numbering, these are decoded when sockets are read
+const (
+	UNCONNECTED State = iota + 100
+	CONNECTING
+	CONNECTED
+	DISCONNECTING
+)
+
+var stateNames = map[State]string{
+	ESTABLISHED:   "ESTABLISHED",
+	SYN_SENT:      "SYN_SENT",
+	SYN_RECV:      "SYN_RECV",
+	FIN_WAIT1:     "FIN_WAIT1",
+	FIN_WAIT2:     "FIN_WAIT2",
+	TIME_WAIT:     "TIME_WAIT",
+	CLOSE:         "CLOSE",
+	CLOSE_WAIT:    "CLOSE_WAIT",
+	LAST_ACK:      "LAST_ACK",
+	LISTEN:        "LISTEN",
+	CLOSING:       "CLOSING",
+	NEW_SYN_RECV:  "NEW_SYN_RECV",
+	UNCONNECTED:   "UNCONNECTED",
+	CONNECTING:    "CONNECTING",
+	CONNECTED:     "CONNECTED",
+	DISCONNECTING: "DISCONNECTING",
+}
+
+// Sockets without a meaningful state (packet and netlink) have the zero state, which has no name
 func (s State) String() string {
-	switch s {
-	case ESTABLISHED:
-		return "ESTABLISHED"
-	case SYN_SENT:
-		return "SYN_SENT"
-	case SYN_RECV:
-		return "SYN_RECV"
-	case FIN_WAIT1:
-		return "FIN_WAIT1"
-	case FIN_WAIT2:
-		return "FIN_WAIT2"
-	case TIME_WAIT:
-		return "TIME_WAIT"
-	case CLOSE:
-		return "CLOSE"
-	case CLOSE_WAIT:
-		return "CLOSE_WAIT"
-	case LAST_ACK:
-		return "LAST_ACK"
-	case LISTEN:
-		return "LISTEN"
-	case CLOSING:
-		return "CLOSING"
-	case NEW_SYN_RECV:
-		return "NEW_SYN_RECV"
+	if s == 0 {
+		return ""
+	}
+	if name, exists := stateNames[s]; exists {
+		return name
 	}
 	return "?"
 }
 
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Unknown names are decoded as an unknown state rather than failing
+func (s *State) UnmarshalText(text []byte) error {
+	*s = 0
+	if len(text) == 0 {
+		return nil
+	}
+	state, err := ParseState(string(text))
+	if err != nil {
+		state = -1
+	}
+	*s = state
+	return nil
+}
+
+// Get a state by name, ex. LISTEN or listen
+func ParseState(name string) (State, error) {
+	for state, n := range stateNames {
+		if strings.EqualFold(n, name) {
+			return state, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown socket state '%s'", name)
+}
+
+// Protocols without connections, their sockets are only connected or unconnected
+var connectionless = []string{"udp", "udplite", "icmp", "raw"}
+
+// Decode the state code of an inet socket from /proc/net/*
+func inetState(protocol string, code int64) State {
+	state := State(code)
+	if slices.Contains(connectionless, protocol) {
+		// connect() on a datagram socket marks it ESTABLISHED, otherwise it is CLOSE
+		switch state {
+		case ESTABLISHED:
+			return CONNECTED
+		case CLOSE:
+			return UNCONNECTED
+		}
+	}
+	return state
+}
+
 // https://www.kernel.org/doc/html/v6.2/networking/proc_net_tcp.html
 type Socket struct {
-	Protocol string // tcp, udp, raw, ...
+	Protocol string `json:"protocol"` // tcp, udp, raw, ...
+	Family   string `json:"family"`   // inet, inet6, unix, packet or netlink
 
 	// Fields from /proc/net/*
-	Sl           int // Slot number (in the socket hashtable)
-	Local_addr   string
-	Local_port   int
-	Remote_addr  string
-	Remote_port  int
-	State        string
-	Tx_queue     int // Size of the transmit queue in bytes
-	Rx_queue     int // Size of the receive queue in bytes
-	Timer_active int // 0 - no timer; 1,2,4 - timer pending; 3 - socket waiting
-	Tm_when      int // Jiffies until timer expires
-	Retrnsmt     int // Number of unrecovered RTO timeouts
-	Uid          int
-	Timeout      int // unanswered 0-window probes
-	Inode        int
-	References   int // Socket reference count
-	Location     int // Address of the socket in memory
+	Sl           int    `json:"sl"` // Slot number (in the socket hashtable)
+	Local_addr   string `json:"local_addr"`
+	Local_port   int    `json:"local_port"`
+	Remote_addr  string `json:"remote_addr"`
+	Remote_port  int    `json:"remote_port"`
+	State        State  `json:"state"`
+	Tx_queue     int    `json:"tx_queue"`     // Size of the transmit queue in bytes
+	Rx_queue     int    `json:"rx_queue"`     // Size of the receive queue in bytes
+	Timer_active int    `json:"timer_active"` // 0 - no timer; 1,2,4 - timer pending; 3 - socket waiting
+	Tm_when      int    `json:"tm_when"`      // Jiffies until timer expires
+	Retrnsmt     int    `json:"retrnsmt"`     // Number of unrecovered RTO timeouts
+	Uid          int    `json:"uid"`
+	Timeout      int    `json:"timeout"` // unanswered 0-window probes
+	Inode        int    `json:"inode"`
+	References   int    `json:"references"` // Socket reference count
+	Location     uint64 `json:"location"`   // Address of the socket in memory
 	// ... (Don't care about the rest)
+
+	// Fields from /proc/net/unix
+	Path  string `json:"path,omitempty"`  // Bound path, abstract names start with @
+	Type  string `json:"type,omitempty"`  // stream, dgram or seqpacket
+	Flags int    `json:"flags,omitempty"` // Socket flags, ex. __SO_ACCEPTCON for listening sockets
+	Peer  int    `json:"peer,omitempty"`  // Inode of the connected peer socket (from sock_diag)
+
+	// Fields from /proc/net/packet and /proc/net/netlink
+	Subprotocol string `json:"subprotocol,omitempty"` // Ethertype for packet sockets, netlink protocol for netlink sockets
+	Ifindex     int    `json:"ifindex,omitempty"`     // Index of the interface a packet socket is bound to, 0 for any
+	Iface       string `json:"iface,omitempty"`       // Name of the interface a packet socket is bound to
+	Portid      int    `json:"portid,omitempty"`      // Netlink port id, usually the pid of the owning process
+	Groups      int    `json:"groups,omitempty"`      // Netlink multicast groups bitmask
+
+	// Fields from sock_diag, only set by the netlink backend
+	Rtt           float64 `json:"rtt,omitempty"`            // Smoothed round trip time in milliseconds (tcp)
+	Rttvar        float64 `json:"rttvar,omitempty"`         // Round trip time variance in milliseconds (tcp)
+	CongState     string  `json:"cong_state,omitempty"`     // Congestion state: open, disorder, cwr, recovery or loss (tcp)
+	Congestion    string  `json:"congestion,omitempty"`     // Congestion control algorithm, ex. cubic (tcp)
+	BytesSent     uint64  `json:"bytes_sent,omitempty"`     // Payload bytes sent, linux 4.19+ (tcp)
+	BytesReceived uint64  `json:"bytes_received,omitempty"` // Payload bytes received (tcp)
+	CgroupId      uint64  `json:"cgroup_id,omitempty"`      // Id of the cgroup v2 the socket was created in, linux 5.7+
+
+	// Set by network.AnnotateListeners
+	Reachable []string `json:"reachable,omitempty"` // Addresses a listener bound to the wildcard address accepts connections on
+
+	// Set by SocketIndex.Annotate
+	Owners  []SocketOwner `json:"owners,omitempty"`  // Processes holding the socket
+	Unowned string        `json:"unowned,omitempty"` // Why no process holds the socket, see OwnerKernel
+	Capture bool          `json:"capture,omitempty"` // A packet socket held by a process, which can sniff traffic
+}
+
+// The protocol name as used by /proc/net, ex. tcp6 for tcp over ipv6
+func (s Socket) ProtocolName() string {
+	switch s.Family {
+	case "inet6":
+		return s.Protocol + "6"
+	case "packet", "netlink":
+		return s.Protocol + "/" + s.Subprotocol
+	}
+	return s.Protocol
+}
+
+// The local address and port, with ipv6 addresses in brackets
+// For unix sockets this is the bound path, for packet sockets the interface
+// and for netlink sockets the port id
+func (s Socket) LocalEndpoint() string {
+	switch s.Family {
+	case "unix":
+		return s.Path
+	case "packet":
+		return s.Iface
+	case "netlink":
+		return fmt.Sprintf("portid:%d", s.Portid)
+	}
+	return net.JoinHostPort(s.Local_addr, strconv.Itoa(s.Local_port))
+}
+
+// The remote address and port, with ipv6 addresses in brackets
+// For unix sockets this is the inode of the peer socket
+func (s Socket) RemoteEndpoint() string {
+	switch s.Family {
+	case "unix":
+		if s.Peer == 0 {
+			return ""
+		}
+		return fmt.Sprintf("i:%d", s.Peer)
+	case "packet", "netlink":
+		return ""
+	}
+	return net.JoinHostPort(s.Remote_addr, strconv.Itoa(s.Remote_port))
+}
+
+// The name of the socket state, empty for sockets without states
+func (s Socket) StateName() string {
+	return s.State.String()
+}
+
+// True for sockets waiting for connections or, for connectionless
+// protocols, bound sockets that receive from anyone
+func (s Socket) Listening() bool {
+	if s.State == LISTEN {
+		return true
+	}
+	return (s.Family == "inet" || s.Family == "inet6") && slices.Contains(connectionless, s.Protocol) &&
+		s.State == UNCONNECTED && s.Local_port != 0
 }
 
 func (s Socket) String() string {
-	state, _ := strconv.ParseInt(s.State, 16, 0)
+	var str string
+	switch s.Family {
+	case "unix":
+		str = s.unixString()
+	case "packet":
+		str = s.packetString()
+	case "netlink":
+		str = s.netlinkString()
+	default:
+		str = s.inetString()
+	}
+	str = strings.TrimSuffix(str, "\n") + s.ownerSuffix()
+	if warning := s.warning(); warning != "" {
+		str += " WARNING: " + warning
+	}
+	return str + "\n"
+}
 
+func (s Socket) inetString() string {
 	arrow := "->"
-	if State(state).String() == "LISTEN" {
+	if s.Listening() {
 		arrow = "<-"
 	}
+	reachable := ""
+	if len(s.Reachable) > 0 {
+		reachable = " on " + strings.Join(s.Reachable, ",")
+	}
 
-	return fmt.Sprintf("<%d> %s %s:%d %s %s:%d (%s) i:%d\n",
+	return fmt.Sprintf("<%d> %s %s %s %s (%s)%s i:%d\n",
 		s.Sl,
-		s.Protocol,
-		s.Local_addr, s.Local_port,
+		s.ProtocolName(),
+		s.LocalName(),
 		arrow,
-		s.Remote_addr, s.Remote_port,
-		State(state).String(),
+		s.RemoteName(),
+		s.StateName(),
+		reachable,
 		s.Inode)
 }
 
 func (s Socket) Describe() string {
 	desc := "┌[%d] (%s)\n"
-	desc += "├ Local: %s:%d\n"
-	desc += "├ Remote: %s:%d\n"
+	desc += "├ Local: %s\n"
+	desc += "├ Remote: %s\n"
 	desc += "├ State: %s\n"
 	desc += "├ Inode: %d\n"
 	desc += "├ References: %d\n"
-	desc += "└ Location: %d\n"
-
-	state, _ := strconv.ParseInt(s.State, 16, 0)
+	desc += "%s"
+	desc += "%s"
+	desc += "└ Location: 0x%x\n"
 
 	return fmt.Sprintf(desc,
 		s.Sl,
-		s.Protocol,
-		s.Local_addr, s.Local_port,
-		s.Remote_addr, s.Remote_port,
-		State(state).String(),
+		s.ProtocolName(),
+		s.LocalName(),
+		s.RemoteName(),
+		s.StateName(),
 		s.Inode,
 		s.References,
+		s.describeDetails(),
+		s.describeOwners(),
 		s.Location)
 }
 
+// The Describe lines of the sock_diag and annotated fields, empty when they weren't set
+func (s Socket) describeDetails() string {
+	desc := ""
+	if len(s.Reachable) > 0 {
+		desc += fmt.Sprintf("├ Reachable: %s\n", strings.Join(s.Reachable, ", "))
+	}
+	if s.Congestion != "" || s.Rtt != 0 {
+		desc += fmt.Sprintf("├ RTT: %.3fms (var %.3fms) congestion: %s %s\n", s.Rtt, s.Rttvar, s.Congestion, s.CongState)
+	}
+	if s.BytesSent != 0 || s.BytesReceived != 0 {
+		desc += fmt.Sprintf("├ Bytes: sent %s received %s\n", utils.FormatBytes(s.BytesSent), utils.FormatBytes(s.BytesReceived))
+	}
+	if s.CgroupId != 0 {
+		desc += fmt.Sprintf("├ Cgroup: %s (id %d)\n", cmp.Or(s.CgroupPath(), "?"), s.CgroupId)
+	}
+	return desc
+}
+
+func (s Socket) Columns() []string {
+	return []string{"SL", "PROTO", "LOCAL", "REMOTE", "STATE", "UID", "INODE", "PID", "PROGRAM", "USER", "WARNING"}
+}
+
+func (s Socket) Row() []string {
+	return []string{
+		strconv.Itoa(s.Sl),
+		s.ProtocolName(),
+		s.LocalName(),
+		s.RemoteName(),
+		s.StateName(),
+		strconv.Itoa(s.Uid),
+		strconv.Itoa(s.Inode),
+		s.ownerPids(),
+		s.ownerPrograms(),
+		s.OwnerUsers(),
+		s.warning(),
+	}
+}
+
+// Decode an address from /proc/net/*, ex. 0100007F:0016 or
+// 00000000000000000000000001000000:0016
+// Addresses are printed as 32 bit words in host byte order
 func decodeAddr(hexAddr string) (ip string, port int) {
-	ipHex := strings.Split(hexAddr, ":")[0]
-	ip = ""
-	for i := 0; i < len(ipHex); i += 2 {
-		ipBytes, _ := hex.DecodeString(ipHex[i : i+2])
-		ip = fmt.Sprintf("%d.", int(ipBytes[0])) + ip
+	ipHex, portHex, _ := strings.Cut(hexAddr, ":")
+
+	ipBytes := make([]byte, 0, 16)
+	for i := 0; i+8 <= len(ipHex); i += 8 {
+		word, _ := strconv.ParseUint(ipHex[i:i+8], 16, 32)
+		ipBytes = binary.NativeEndian.AppendUint32(ipBytes, uint32(word))
+	}
+	if addr, ok := netip.AddrFromSlice(ipBytes); ok {
+		// v4-mapped ipv6 addresses keep their ::ffff: prefix
+		ip = addr.String()
 	}
-	ip = ip[:len(ip)-1]
 
-	portBytes, _ := hex.DecodeString(strings.Split(hexAddr, ":")[1])
-	port = int(binary.BigEndian.Uint16(portBytes))
+	portBytes, _ := hex.DecodeString(portHex)
+	if len(portBytes) == 2 {
+		port = int(binary.BigEndian.Uint16(portBytes))
+	}
 
 	return ip, port
 }
 
 func GetSockets() (sockets []Socket) {
-	// TODO: Handle ipv6
 	// TODO: /proc/net/icmp seems to provide mostly useless data
 	protocols := []string{"tcp", "udp", "udplite", "icmp", "raw"}
+	families := map[string]string{"": "inet", "6": "inet6"}
 
 	for _, proto := range protocols {
-		path := fmt.Sprintf("/proc/net/%s", proto)
-		contents, err := os.ReadFile(path)
-		if err != nil {
-			slog.Debug("Failed to read file", "path", path, "error", err.Error())
+		for _, suffix := range []string{"", "6"} {
+			sockets = append(sockets, readInetSockets(proto, families[suffix], proto+suffix)...)
+		}
+	}
+	sockets = append(sockets, readUnixSockets()...)
+	sockets = append(sockets, readPacketSockets()...)
+	sockets = append(sockets, readNetlinkSockets()...)
+
+	return sockets
+}
+
+// Read the sockets in /proc/net/<table>
+func readSockets(proto string, family string, table string) (sockets []Socket) {
+	path := fmt.Sprintf("net/%s", table)
+	contents, err := sysfs.Proc.ReadFile(path)
+	if err != nil {
+		slog.Debug("Failed to read file", "path", path, "error", err.Error())
+		return nil
+	}
+	for n, line := range strings.Split(string(contents), "\n") {
+		// Skip header and empty lines
+		if n == 0 || strings.TrimSpace(line) == "" {
 			continue
 		}
-		for n, line := range strings.Split(string(contents), "\n") {
-			// Skip header and empty lines
-			if n == 0 || strings.TrimSpace(line) == "" {
-				continue
-			}
-
-			//fmt.Printf("%s socket: <%s>\n", proto, line)
-
-			sock_data := make([]string, 0)
-			for _, f := range strings.Split(line, " ") {
-				if f == "" {
-					continue
-				}
-				sock_data = append(sock_data, f)
-			}
-
-			// Map data into Socket
-
-			// Example (tcp):
-			// sl local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
-			// 0: 00000000:002A 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 78428 1 0000000000000000 100 0 0 10 0
-
-			socket := Socket{}
-			socket.Protocol = proto
-
-			socket.Sl, _ = strconv.Atoi(strings.Split(sock_data[0], ":")[0])
-			socket.Local_addr, socket.Local_port = decodeAddr(sock_data[1])
-			socket.Remote_addr, socket.Remote_port = decodeAddr(sock_data[2])
-			socket.State = sock_data[3]
-			socket.Tx_queue, _ = strconv.Atoi(strings.Split(sock_data[4], ":")[0])
-			socket.Rx_queue, _ = strconv.Atoi(strings.Split(sock_data[4], ":")[1])
-			socket.Timer_active, _ = strconv.Atoi(strings.Split(sock_data[5], ":")[0])
-			socket.Tm_when, _ = strconv.Atoi(strings.Split(sock_data[5], ":")[1])
-			socket.Retrnsmt, _ = strconv.Atoi(sock_data[6])
-			socket.Uid, _ = strconv.Atoi(sock_data[7])
-			socket.Timeout, _ = strconv.Atoi(sock_data[8])
-			socket.Inode, _ = strconv.Atoi(sock_data[9])
-			socket.References, _ = strconv.Atoi(sock_data[10])
-			socket.Location, _ = strconv.Atoi(sock_data[11])
-
-			sockets = append(sockets, socket)
+
+		sock_data := strings.Fields(line)
+		if len(sock_data) < 12 {
+			slog.Debug("Skipping malformed socket entry", "path", path, "line", line)
+			continue
 		}
+
+		// Map data into Socket
+
+		// Example (tcp):
+		// sl local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+		// 0: 00000000:002A 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 78428 1 0000000000000000 100 0 0 10 0
+
+		socket := Socket{}
+		socket.Protocol = proto
+		socket.Family = family
+
+		socket.Sl, _ = strconv.Atoi(strings.Split(sock_data[0], ":")[0])
+		socket.Local_addr, socket.Local_port = decodeAddr(sock_data[1])
+		socket.Remote_addr, socket.Remote_port = decodeAddr(sock_data[2])
+		state, _ := strconv.ParseInt(sock_data[3], 16, 0)
+		socket.State = inetState(proto, state)
+		socket.Tx_queue, _ = strconv.Atoi(strings.Split(sock_data[4], ":")[0])
+		socket.Rx_queue, _ = strconv.Atoi(strings.Split(sock_data[4], ":")[1])
+		socket.Timer_active, _ = strconv.Atoi(strings.Split(sock_data[5], ":")[0])
+		socket.Tm_when, _ = strconv.Atoi(strings.Split(sock_data[5], ":")[1])
+		socket.Retrnsmt, _ = strconv.Atoi(sock_data[6])
+		socket.Uid, _ = strconv.Atoi(sock_data[7])
+		socket.Timeout, _ = strconv.Atoi(sock_data[8])
+		socket.Inode, _ = strconv.Atoi(sock_data[9])
+		socket.References, _ = strconv.Atoi(sock_data[10])
+		socket.Location, _ = strconv.ParseUint(sock_data[11], 16, 64)
+
+		sockets = append(sockets, socket)
 	}
 
 	return sockets
@@ -0,0 +1,128 @@
+package proc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeAddr(t *testing.T) {
+	tests := []struct {
+		hex  string
+		ip   string
+		port int
+	}{
+		{"0100007F:0016", "127.0.0.1", 22},
+		{"00000000:0000", "0.0.0.0", 0},
+		{"0A01A8C0:C822", "192.168.1.10", 51234},
+		{"00000000000000000000000001000000:0050", "::1", 80},
+		{"0000000000000000FFFF00000100007F:01BB", "::ffff:127.0.0.1", 443},
+		{"invalid", "", 0},
+	}
+	for _, tt := range tests {
+		ip, port := decodeAddr(tt.hex)
+		if ip != tt.ip || port != tt.port {
+			t.Errorf("decodeAddr(%q) = %q, %d, want %q, %d", tt.hex, ip, port, tt.ip, tt.port)
+		}
+	}
+}
+
+// The fields of an inet socket compared by TestReadSockets
+type inetFields struct {
+	Sl          int
+	Local_addr  string
+	Local_port  int
+	Remote_addr string
+	Remote_port int
+	State       State
+	Uid         int
+	Inode       int
+	Location    uint64
+}
+
+func TestReadSockets(t *testing.T) {
+	tests := []struct {
+		proto  string
+		family string
+		table  string
+		want   []inetFields
+	}{
+		{"tcp", "inet", "tcp", []inetFields{
+			{0, "0.0.0.0", 22, "0.0.0.0", 0, LISTEN, 0, 1001, 0},
+			{1, "192.168.1.10", 22, "192.168.1.20", 51234, ESTABLISHED, 0, 1002, 0xffff888004a3d800},
+			{2, "127.0.0.1", 8080, "127.0.0.1", 54321, TIME_WAIT, 0, 0, 0},
+		}},
+		{"tcp", "inet6", "tcp6", []inetFields{
+			{0, "::", 80, "::", 0, LISTEN, 33, 2001, 0},
+			{1, "::ffff:127.0.0.1", 80, "::ffff:127.0.0.1", 45732, ESTABLISHED, 33, 2002, 0},
+		}},
+		// udp sockets report tcp states, which are decoded to connected or not
+		{"udp", "inet", "udp", []inetFields{
+			{123, "0.0.0.0", 53, "0.0.0.0", 0, UNCONNECTED, 101, 3001, 0},
+			{456, "192.168.1.10", 41394, "8.8.8.8", 53, CONNECTED, 1000, 3002, 0},
+		}},
+		{"udp", "inet6", "udp6", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			var got []inetFields
+			for _, s := range readSockets(tt.proto, tt.family, tt.table) {
+				if s.Protocol != tt.proto || s.Family != tt.family {
+					t.Errorf("got protocol %q family %q, want %q %q", s.Protocol, s.Family, tt.proto, tt.family)
+				}
+				got = append(got, inetFields{s.Sl, s.Local_addr, s.Local_port, s.Remote_addr, s.Remote_port, s.State, s.Uid, s.Inode, s.Location})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadUnixSockets(t *testing.T) {
+	want := []Socket{
+		{Protocol: "unix", Family: "unix", Sl: 0, State: LISTEN, Inode: 4001, References: 2, Path: "/run/docker.sock", Type: "stream", Flags: unixAcceptCon, Location: 0xffff8880062c5c00},
+		// Paths may contain spaces
+		{Protocol: "unix", Family: "unix", Sl: 1, State: CONNECTED, Inode: 4002, References: 3, Path: "/run/user/1000/my socket", Type: "stream"},
+		{Protocol: "unix", Family: "unix", Sl: 2, State: UNCONNECTED, Inode: 4003, References: 2, Path: "@/tmp/.X11-unix/X0", Type: "dgram"},
+		{Protocol: "unix", Family: "unix", Sl: 3, State: CONNECTED, Inode: 4004, References: 3, Type: "seqpacket"},
+	}
+	if got := readUnixSockets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReadPacketSockets(t *testing.T) {
+	// Interfaces are only resolved to names on the running system
+	want := []Socket{
+		{Protocol: "packet", Family: "packet", Sl: 0, Type: "raw", Subprotocol: "ETH_P_ALL", Ifindex: 0, Iface: "any", References: 3, Inode: 5001},
+		{Protocol: "packet", Family: "packet", Sl: 1, Type: "dgram", Subprotocol: "ETH_P_ARP", Ifindex: 2, Iface: "2", References: 3, Rx_queue: 2304, Uid: 1000, Inode: 5002, Location: 0xffff88800a1b2c00},
+		{Protocol: "packet", Family: "packet", Sl: 2, Type: "raw", Subprotocol: "0x1234", Ifindex: 0, Iface: "any", References: 3, Inode: 5003},
+	}
+	if got := readPacketSockets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReadNetlinkSockets(t *testing.T) {
+	want := []Socket{
+		{Protocol: "netlink", Family: "netlink", Sl: 0, Subprotocol: "route", Portid: 0, Inode: 6001},
+		{Protocol: "netlink", Family: "netlink", Sl: 1, Subprotocol: "route", Portid: 1234, Groups: 0x551, Inode: 6002},
+		{Protocol: "netlink", Family: "netlink", Sl: 2, Subprotocol: "audit", Portid: 4242, Groups: 0x1, Inode: 6003},
+		{Protocol: "netlink", Family: "netlink", Sl: 3, Subprotocol: "31", Portid: 99, Inode: 6004},
+	}
+	if got := readNetlinkSockets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestGetSockets(t *testing.T) {
+	// An alternate /proc is always read from the text tables
+	counts := make(map[string]int)
+	for _, s := range GetSockets() {
+		counts[s.Protocol+" "+s.Family] += 1
+	}
+	want := map[string]int{"tcp inet": 3, "tcp inet6": 2, "udp inet": 2, "unix unix": 4, "packet packet": 3, "netlink netlink": 4}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("got %v, want %v", counts, want)
+	}
+}
@@ -0,0 +1,140 @@
+package proc
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Criteria for selecting sockets, empty fields match every socket
+type SocketFilter struct {
+	States      []State
+	Protocols   []string       // ex. tcp matches tcp over ipv4 and ipv6, tcp6 only over ipv6
+	LocalPorts  []int          // Only inet sockets have ports
+	RemotePorts []int          // Only inet sockets have ports
+	LocalNets   []netip.Prefix // Only inet sockets have addresses
+	RemoteNets  []netip.Prefix // Only inet sockets have addresses
+	Inodes      []int
+	Listening   bool  // See Socket.Listening
+	Uids        []int // Unix and netlink sockets don't record an owner and never match
+}
+
+// Parse a network such as 10.0.0.0/8, a single address matches only itself
+func ParsePrefix(value string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address or network '%s'", value)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// True if the address is in any of the networks
+func inNets(value string, nets []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return false
+	}
+	// v4-mapped ipv6 addresses match ipv4 networks
+	addr = addr.Unmap()
+	return slices.ContainsFunc(nets, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+func (f SocketFilter) Match(s Socket) bool {
+	inet := s.Family == "inet" || s.Family == "inet6"
+	if len(f.States) > 0 && !slices.Contains(f.States, s.State) {
+		return false
+	}
+	if len(f.Protocols) > 0 && !slices.Contains(f.Protocols, s.Protocol) && !slices.Contains(f.Protocols, s.ProtocolName()) {
+		return false
+	}
+	if len(f.LocalPorts) > 0 && (!inet || !slices.Contains(f.LocalPorts, s.Local_port)) {
+		return false
+	}
+	if len(f.RemotePorts) > 0 && (!inet || !slices.Contains(f.RemotePorts, s.Remote_port)) {
+		return false
+	}
+	if len(f.LocalNets) > 0 && (!inet || !inNets(s.Local_addr, f.LocalNets)) {
+		return false
+	}
+	if len(f.RemoteNets) > 0 && (!inet || !inNets(s.Remote_addr, f.RemoteNets)) {
+		return false
+	}
+	if len(f.Inodes) > 0 && !slices.Contains(f.Inodes, s.Inode) {
+		return false
+	}
+	if f.Listening && !s.Listening() {
+		return false
+	}
+	// /proc/net/unix and /proc/net/netlink have no uid column, their zero uid isn't root
+	owned := s.Family != "unix" && s.Family != "netlink"
+	if len(f.Uids) > 0 && (!owned || !slices.Contains(f.Uids, s.Uid)) {
+		return false
+	}
+	return true
+}
+
+// Get the sockets matching the filter
+func FilterSockets(sockets []Socket, f SocketFilter) []Socket {
+	matches := make([]Socket, 0)
+	for _, s := range sockets {
+		if f.Match(s) {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+// Orders available for SortSockets
+var SocketSorts = []string{"proto", "local", "lport", "remote", "rport", "state", "uid", "inode"}
+
+// Compare the addresses of two sockets, parseable addresses sort first and the
+// rest (ex. packet and unix sockets without addresses) after them as strings
+func compareAddrs(a string, b string) int {
+	addr_a, err_a := netip.ParseAddr(a)
+	addr_b, err_b := netip.ParseAddr(b)
+	switch {
+	case err_a == nil && err_b == nil:
+		return addr_a.Unmap().Compare(addr_b.Unmap())
+	case err_a == nil:
+		return -1
+	case err_b == nil:
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
+
+// Sort sockets in place, sockets that compare equal keep their order
+func SortSockets(sockets []Socket, by string) error {
+	var compare func(a Socket, b Socket) int
+	switch by {
+	case "proto":
+		compare = func(a Socket, b Socket) int { return cmp.Compare(a.ProtocolName(), b.ProtocolName()) }
+	case "local":
+		compare = func(a Socket, b Socket) int {
+			return cmp.Or(compareAddrs(a.Local_addr, b.Local_addr), cmp.Compare(a.Local_port, b.Local_port), strings.Compare(a.Path, b.Path))
+		}
+	case "lport":
+		compare = func(a Socket, b Socket) int { return cmp.Compare(a.Local_port, b.Local_port) }
+	case "remote":
+		compare = func(a Socket, b Socket) int {
+			return cmp.Or(compareAddrs(a.Remote_addr, b.Remote_addr), cmp.Compare(a.Remote_port, b.Remote_port))
+		}
+	case "rport":
+		compare = func(a Socket, b Socket) int { return cmp.Compare(a.Remote_port, b.Remote_port) }
+	case "state":
+		compare = func(a Socket, b Socket) int { return cmp.Compare(a.StateName(), b.StateName()) }
+	case "uid":
+		compare = func(a Socket, b Socket) int { return cmp.Compare(a.Uid, b.Uid) }
+	case "inode":
+		compare = func(a Socket, b Socket) int { return cmp.Compare(a.Inode, b.Inode) }
+	default:
+		return fmt.Errorf("unknown sort '%s' (expected one of %v)", by, SocketSorts)
+	}
+	slices.SortStableFunc(sockets, compare)
+	return nil
+}
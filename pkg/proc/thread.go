@@ -0,0 +1,173 @@
+package proc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"seer/pkg/sysfs"
+)
+
+// A thread of a process read from /proc/[pid]/task/[tid]
+type Thread struct {
+	Tid       int          `json:"tid"`       // thread id
+	Tgid      int          `json:"tgid"`      // id of the thread group, the pid of the process
+	Comm      string       `json:"comm"`      // thread name
+	State     rune         `json:"state"`     // thread state
+	Utime     uint64       `json:"utime"`     // clock ticks scheduled in user mode
+	Stime     uint64       `json:"stime"`     // clock ticks scheduled in kernel mode
+	Nice      int64        `json:"nice"`      // the nice value
+	Starttime uint64       `json:"starttime"` // clock ticks since boot at thread start
+	Started   time.Time    `json:"started"`   // when the thread started, derived from Starttime and the boot time
+	Processor int          `json:"processor"` // cpu the thread last ran on
+	Uid       int          `json:"uid"`       // real user id
+	Euid      int          `json:"euid"`      // effective user id
+	CapEff    Capabilities `json:"cap_eff"`   // effective capabilities
+
+	Renamed  bool     `json:"renamed"`  // True if the name differs from the thread group leader's
+	Warnings []string `json:"warnings"` // Reasons the thread looks suspicious
+}
+
+// Names of kernel threads, a user thread renamed to one of these is hiding
+var KernelThreadPattern = regexp.MustCompile(`^(\[.*\]|kworker/.*|ksoftirqd/.*|migration/.*|rcu_.*|kthreadd|kswapd.*|watchdog/.*|irq/.*|cpuhp/.*)$`)
+
+// Get the seconds of cpu time used by the thread
+func (t Thread) CPUTime() float64 {
+	return ticksToSeconds(t.Utime + t.Stime)
+}
+
+// Get the percentage of a cpu the thread used over its lifetime
+func (t Thread) CPUPercent() float64 {
+	if t.Started.IsZero() {
+		return 0
+	}
+	age := time.Since(t.Started).Seconds()
+	if age <= 0 {
+		return 0
+	}
+	return 100 * t.CPUTime() / age
+}
+
+func (t Thread) String() string {
+	warnings := ""
+	if t.Renamed {
+		warnings = " (renamed)"
+	}
+	if len(t.Warnings) > 0 {
+		warnings += " WARNING: " + strings.Join(t.Warnings, ", ")
+	}
+	return fmt.Sprintf("{%d} %s %c cpu: %.1f%% (%.0fs) core: %d%s\n",
+		t.Tid, t.Comm, t.State, t.CPUPercent(), t.CPUTime(), t.Processor, warnings,
+	)
+}
+
+func (t Thread) Columns() []string {
+	return []string{"TID", "COMM", "STATE", "CPU%", "TIME", "CORE", "WARNINGS"}
+}
+
+func (t Thread) Row() []string {
+	return []string{
+		strconv.Itoa(t.Tid),
+		t.Comm,
+		string(t.State),
+		fmt.Sprintf("%.1f", t.CPUPercent()),
+		fmt.Sprintf("%.0fs", t.CPUTime()),
+		strconv.Itoa(t.Processor),
+		strings.Join(t.Warnings, ", "),
+	}
+}
+
+// Threads are encoded with the state as a string like processes
+func (t Thread) MarshalJSON() ([]byte, error) {
+	type thread Thread
+	return json.Marshal(struct {
+		thread
+		State      string  `json:"state"`
+		CPUPercent float64 `json:"cpu_percent"`
+	}{thread(t), string(t.State), t.CPUPercent()})
+}
+
+// Read a thread from /proc/[pid]/task/[tid]
+func getThread(pid int, tid int) (Thread, error) {
+	t := Thread{Tid: tid, Tgid: pid, Warnings: []string{}}
+	dir := fmt.Sprintf("%d/task/%d", pid, tid)
+
+	stat, err := sysfs.Proc.ReadFile(dir + "/stat")
+	if err != nil || len(stat) == 0 {
+		return t, fmt.Errorf("the thread '%d' does not exist", tid)
+	}
+	// comm is read from its own file, only the fields after it are used from stat
+	stat_str := string(stat)
+	fields := strings.Fields(stat_str[strings.LastIndexByte(stat_str, ')')+1:])
+	if len(fields) < 37 {
+		return t, fmt.Errorf("malformed stat for thread '%d'", tid)
+	}
+	t.State = []rune(fields[0])[0]
+	t.Utime, _ = strconv.ParseUint(fields[11], 10, 64)
+	t.Stime, _ = strconv.ParseUint(fields[12], 10, 64)
+	t.Nice, _ = strconv.ParseInt(fields[16], 10, 64)
+	t.Starttime, _ = strconv.ParseUint(fields[19], 10, 64)
+	t.Started = startedAt(t.Starttime)
+	t.Processor, _ = strconv.Atoi(fields[36])
+
+	comm, err := sysfs.Proc.ReadFile(dir + "/comm")
+	if err != nil {
+		comm = []byte(stat_str[strings.IndexByte(stat_str, '(')+1 : strings.LastIndexByte(stat_str, ')')])
+	}
+	t.Comm = strings.TrimSuffix(string(comm), "\n")
+
+	status, _ := sysfs.Proc.ReadFile(dir + "/status")
+	for _, line := range strings.Split(string(status), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Uid":
+			fmt.Sscanf(value, "%d %d", &t.Uid, &t.Euid)
+		case "CapEff":
+			t.CapEff = parseCapabilities(value)
+		}
+	}
+	return t, nil
+}
+
+// Get the threads of the process, including the thread group leader
+func (p Process) GetThreads() ([]Thread, error) {
+	tasks, err := sysfs.Proc.ReadDir(fmt.Sprintf("%d/task", p.Pid))
+	if err != nil {
+		return nil, err
+	}
+	leader := p.Comm
+	if comm, err := sysfs.Proc.ReadFile(fmt.Sprintf("%d/comm", p.Pid)); err == nil {
+		leader = strings.TrimSuffix(string(comm), "\n")
+	}
+
+	threads := make([]Thread, 0, len(tasks))
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		t, err := getThread(p.Pid, tid)
+		if err != nil {
+			// The thread exited while it was being read
+			continue
+		}
+		if tid != p.Pid && t.Comm != leader {
+			t.Renamed = true
+			if KernelThreadPattern.MatchString(t.Comm) {
+				t.Warnings = append(t.Warnings, fmt.Sprintf("named like a kernel thread in %s", leader))
+			}
+		}
+		if t.Uid != p.Uid || t.Euid != p.Euid {
+			t.Warnings = append(t.Warnings, fmt.Sprintf("uid %d euid %d differ from the process", t.Uid, t.Euid))
+		}
+		threads = append(threads, t)
+	}
+	return threads, nil
+}
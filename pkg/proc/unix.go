@@ -0,0 +1,165 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"seer/pkg/sysfs"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Unix socket states
+// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/uapi/linux/net.h
+
+const (
+	SS_UNCONNECTED   = 1
+	SS_CONNECTING    = 2
+	SS_CONNECTED     = 3
+	SS_DISCONNECTING = 4
+)
+
+// Set in the flags of listening unix sockets
+const unixAcceptCon = 0x10000 // __SO_ACCEPTCON
+
+var unixTypes = map[int]string{
+	syscall.SOCK_STREAM:    "stream",
+	syscall.SOCK_DGRAM:     "dgram",
+	syscall.SOCK_SEQPACKET: "seqpacket",
+}
+
+// Decode the state of a unix socket from /proc/net/unix
+func unixState(state int64, flags int) State {
+	if flags&unixAcceptCon != 0 {
+		return LISTEN
+	}
+	switch state {
+	case SS_UNCONNECTED:
+		return UNCONNECTED
+	case SS_CONNECTING:
+		return CONNECTING
+	case SS_CONNECTED:
+		return CONNECTED
+	case SS_DISCONNECTING:
+		return DISCONNECTING
+	}
+	return State(-1)
+}
+
+// Read the unix sockets in /proc/net/unix
+func readUnixSockets() (sockets []Socket) {
+	path := "net/unix"
+	contents, err := sysfs.Proc.ReadFile(path)
+	if err != nil {
+		slog.Debug("Failed to read file", "path", path, "error", err.Error())
+		return nil
+	}
+
+	// Peers are only available from the running kernel
+	peers := map[int]int{}
+	if sysfs.LiveProc() {
+		peers, err = getUnixPeers()
+		if err != nil {
+			slog.Debug("Failed to get unix socket peers", "error", err.Error())
+		}
+	}
+
+	for n, line := range strings.Split(string(contents), "\n") {
+		// Skip header and empty lines
+		if n == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Example:
+		// Num       RefCount Protocol Flags    Type St Inode Path
+		// 0000000000000000: 00000002 00000000 00010000 0001 01 12345 /run/docker.sock
+
+		sock_data := strings.Fields(line)
+		if len(sock_data) < 7 {
+			slog.Debug("Skipping malformed socket entry", "path", path, "line", line)
+			continue
+		}
+
+		socket := Socket{}
+		socket.Protocol = "unix"
+		socket.Family = "unix"
+
+		socket.Sl = n - 1
+		socket.Location, _ = strconv.ParseUint(strings.TrimSuffix(sock_data[0], ":"), 16, 64)
+		references, _ := strconv.ParseInt(sock_data[1], 16, 0)
+		socket.References = int(references)
+		flags, _ := strconv.ParseInt(sock_data[3], 16, 0)
+		socket.Flags = int(flags)
+		sock_type, _ := strconv.ParseInt(sock_data[4], 16, 0)
+		socket.Type = unixTypes[int(sock_type)]
+		state, _ := strconv.ParseInt(sock_data[5], 16, 0)
+		socket.State = unixState(state, socket.Flags)
+		socket.Inode, _ = strconv.Atoi(sock_data[6])
+		socket.Peer = peers[socket.Inode]
+
+		// Paths may contain spaces so take everything after the inode
+		rest := line
+		for i := 0; i < 7 && rest != ""; i++ {
+			rest = strings.TrimLeft(rest, " ")
+			if end := strings.IndexByte(rest, ' '); end != -1 {
+				rest = rest[end:]
+			} else {
+				rest = ""
+			}
+		}
+		socket.Path = strings.TrimSpace(rest)
+
+		sockets = append(sockets, socket)
+	}
+
+	return sockets
+}
+
+// Get a map of unix socket inode -> peer inode using sock_diag
+// /proc/net/unix does not include the peer of connected sockets
+func getUnixPeers() (map[int]int, error) {
+	// struct unix_diag_req
+	request := make([]byte, 24)
+	request[0] = syscall.AF_UNIX
+	binary.NativeEndian.PutUint32(request[4:8], 0xffffffff) // all states
+	binary.NativeEndian.PutUint32(request[12:16], 4)        // UDIAG_SHOW_PEER
+
+	msgs, err := sockDiag(sockDiagByFamily, request, syscall.NLM_F_DUMP)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make(map[int]int)
+	for _, m := range msgs {
+		// struct unix_diag_msg is 16 bytes followed by attributes
+		if len(m) < 16 {
+			continue
+		}
+		inode := int(binary.NativeEndian.Uint32(m[4:8]))
+		for _, attr := range parseDiagAttrs(m[16:]) {
+			if attr.Type == 2 && len(attr.Data) >= 4 { // UNIX_DIAG_PEER
+				peers[inode] = int(binary.NativeEndian.Uint32(attr.Data))
+			}
+		}
+	}
+	return peers, nil
+}
+
+func (s Socket) unixString() string {
+	path := s.Path
+	if path == "" {
+		path = "-"
+	}
+	peer := ""
+	if s.Peer != 0 {
+		peer = fmt.Sprintf(" -> i:%d", s.Peer)
+	}
+	return fmt.Sprintf("<%d> unix %s %s%s (%s) i:%d\n",
+		s.Sl,
+		s.Type,
+		path,
+		peer,
+		s.StateName(),
+		s.Inode)
+}
@@ -0,0 +1,175 @@
+package proc
+
+import (
+	"fmt"
+	"os"
+	"seer/pkg/sysfs"
+	"seer/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resource usage of a process over a sampling interval
+type Usage struct {
+	Pid        int     `json:"pid"`
+	Comm       string  `json:"comm"`
+	User       string  `json:"user"`
+	Exe        string  `json:"exe"`
+	CPU        float64 `json:"cpu_percent"` // Percentage of one cpu used during the interval
+	RSS        uint64  `json:"rss"`         // Resident memory in bytes
+	PSS        uint64  `json:"pss"`         // Proportional share of resident memory in bytes, 0 if unavailable
+	ReadBytes  uint64  `json:"read_bytes"`  // Bytes read from storage since the process started
+	WriteBytes uint64  `json:"write_bytes"` // Bytes written to storage since the process started
+	IORate     float64 `json:"io_rate"`     // Bytes per second read and written during the interval
+	Age        int     `json:"age"`
+
+	process Process
+}
+
+func (u Usage) String() string {
+	return fmt.Sprintf("[%d] %s %s cpu: %.1f%% rss: %s pss: %s io: %s/s age: %ds\n",
+		u.Pid, u.Comm, u.User, u.CPU, utils.FormatBytes(u.RSS), utils.FormatBytes(u.PSS),
+		utils.FormatBytes(uint64(u.IORate)), u.Age,
+	)
+}
+
+func (u Usage) Columns() []string {
+	return []string{"PID", "USER", "CPU%", "RSS", "PSS", "READ", "WRITE", "IO/S", "AGE", "COMM"}
+}
+
+func (u Usage) Row() []string {
+	return []string{
+		strconv.Itoa(u.Pid),
+		u.User,
+		fmt.Sprintf("%.1f", u.CPU),
+		utils.FormatBytes(u.RSS),
+		utils.FormatBytes(u.PSS),
+		utils.FormatBytes(u.ReadBytes),
+		utils.FormatBytes(u.WriteBytes),
+		utils.FormatBytes(uint64(u.IORate)),
+		fmt.Sprintf("%ds", u.Age),
+		u.Comm,
+	}
+}
+
+// Get the process the usage was sampled from
+func (u Usage) Process() Process {
+	return u.process
+}
+
+// Counters read for each process on every sample
+type usageSample struct {
+	starttime uint64 // Used to tell reused pids apart
+	ticks     uint64 // utime + stime
+	io        uint64 // read_bytes + write_bytes
+}
+
+// Samples process counters and computes rates between consecutive samples
+type Sampler struct {
+	prev map[int]usageSample
+	at   time.Time
+}
+
+// Read the cpu time and start time of a process from /proc/[pid]/stat
+func readCPUTicks(pid int) (ticks uint64, starttime uint64, err error) {
+	data, err := sysfs.Proc.ReadFile(strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	if len(fields) < 20 {
+		return 0, 0, fmt.Errorf("malformed stat for process '%d'", pid)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	starttime, _ = strconv.ParseUint(fields[19], 10, 64)
+	return utime + stime, starttime, nil
+}
+
+// Read the storage io counters from /proc/[pid]/io, usually only readable by the owner
+func readIO(pid int) (read uint64, write uint64, err error) {
+	data, err := sysfs.Proc.ReadFile(strconv.Itoa(pid) + "/io")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "read_bytes":
+			read, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		case "write_bytes":
+			write, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	return read, write, nil
+}
+
+// Read the resident memory in bytes from /proc/[pid]/statm
+func readRSS(pid int) uint64 {
+	data, err := sysfs.Proc.ReadFile(strconv.Itoa(pid) + "/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, _ := strconv.ParseUint(fields[1], 10, 64)
+	return pages * uint64(os.Getpagesize())
+}
+
+// Read the proportional set size in bytes from /proc/[pid]/smaps_rollup
+func readPSS(pid int) uint64 {
+	data, err := sysfs.Proc.ReadFile(strconv.Itoa(pid) + "/smaps_rollup")
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, found := strings.CutPrefix(line, "Pss:"); found {
+			kb, _ := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// Sample the usage of every process
+// Rates are computed against the previous call, so the first call only has
+// totals and a cpu percentage averaged over the lifetime of each process
+func (s *Sampler) Sample(procs map[int]Process) []Usage {
+	now := time.Now()
+	elapsed := now.Sub(s.at).Seconds()
+	uptime, _ := readUptime()
+	current := make(map[int]usageSample)
+
+	usage := make([]Usage, 0, len(procs))
+	for pid, p := range procs {
+		ticks, starttime, err := readCPUTicks(pid)
+		if err != nil {
+			// The process exited after it was listed
+			continue
+		}
+		read, write, _ := readIO(pid)
+		sample := usageSample{starttime: starttime, ticks: ticks, io: read + write}
+		current[pid] = sample
+
+		u := Usage{
+			Pid: pid, Comm: p.Comm, User: p.User.Username, Exe: p.Exelink,
+			RSS: readRSS(pid), PSS: readPSS(pid), ReadBytes: read, WriteBytes: write,
+			Age: p.Age(), process: p,
+		}
+		if prev, exists := s.prev[pid]; exists && prev.starttime == starttime && elapsed > 0 {
+			u.CPU = 100 * ticksToSeconds(ticks-prev.ticks) / elapsed
+			if sample.io >= prev.io {
+				u.IORate = float64(sample.io-prev.io) / elapsed
+			}
+		} else if lifetime := uptime - ticksToSeconds(starttime); lifetime > 0 {
+			u.CPU = 100 * ticksToSeconds(ticks) / lifetime
+		}
+		usage = append(usage, u)
+	}
+	s.prev = current
+	s.at = now
+	return usage
+}
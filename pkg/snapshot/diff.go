@@ -0,0 +1,287 @@
+package snapshot
+
+import (
+	"fmt"
+	"log/slog"
+	"seer/pkg/proc"
+	"seer/pkg/users"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of changes between snapshots
+const (
+	UserAdded          = "user_added"
+	UserRemoved        = "user_removed"
+	UserChanged        = "user_changed"
+	GroupAdded         = "group_added"
+	GroupRemoved       = "group_removed"
+	GroupMemberAdded   = "group_member_added"
+	GroupMemberRemoved = "group_member_removed"
+	ListenerAdded      = "listener_added"
+	ListenerRemoved    = "listener_removed"
+	ExecutableAdded    = "executable_added"
+)
+
+type Change struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`         // The user, group, socket or executable that changed
+	Field   string `json:"field,omitempty"` // The field that changed for user_changed
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+	Detail  string `json:"detail,omitempty"` // Extra context such as the processes holding a socket
+}
+
+type Diff struct {
+	Old     time.Time `json:"old"`
+	New     time.Time `json:"new"`
+	Changes []Change  `json:"changes"`
+}
+
+func (c Change) String() string {
+	detail := ""
+	if c.Detail != "" {
+		detail = fmt.Sprintf(" (%s)", c.Detail)
+	}
+	switch c.Kind {
+	case UserAdded:
+		return fmt.Sprintf("+ user %s%s\n", c.Subject, detail)
+	case UserRemoved:
+		return fmt.Sprintf("- user %s%s\n", c.Subject, detail)
+	case UserChanged:
+		if c.Field == "password" {
+			// Don't print hashes
+			return fmt.Sprintf("~ user %s password hash changed\n", c.Subject)
+		}
+		return fmt.Sprintf("~ user %s %s: %s -> %s\n", c.Subject, c.Field, c.Old, c.New)
+	case GroupAdded:
+		return fmt.Sprintf("+ group %s%s\n", c.Subject, detail)
+	case GroupRemoved:
+		return fmt.Sprintf("- group %s%s\n", c.Subject, detail)
+	case GroupMemberAdded:
+		return fmt.Sprintf("+ group %s member %s\n", c.Subject, c.New)
+	case GroupMemberRemoved:
+		return fmt.Sprintf("- group %s member %s\n", c.Subject, c.Old)
+	case ListenerAdded:
+		return fmt.Sprintf("+ listener %s%s\n", c.Subject, detail)
+	case ListenerRemoved:
+		return fmt.Sprintf("- listener %s%s\n", c.Subject, detail)
+	case ExecutableAdded:
+		return fmt.Sprintf("+ executable %s %s%s\n", c.Subject, c.New, detail)
+	}
+	return fmt.Sprintf("? %s %s\n", c.Kind, c.Subject)
+}
+
+func (c Change) Columns() []string {
+	return []string{"KIND", "SUBJECT", "FIELD", "OLD", "NEW", "DETAIL"}
+}
+
+func (c Change) Row() []string {
+	old, current := c.Old, c.New
+	if c.Field == "password" {
+		old, current = "-", "-"
+	}
+	return []string{c.Kind, c.Subject, c.Field, old, current, c.Detail}
+}
+
+// Get the changes needed to get from old to current
+func Compare(old Snapshot, current Snapshot) Diff {
+	diff := Diff{Old: old.Time, New: current.Time, Changes: make([]Change, 0)}
+	diff.Changes = append(diff.Changes, compareUsers(old.Users, current.Users)...)
+	diff.Changes = append(diff.Changes, compareGroups(old.Groups, current.Groups)...)
+	diff.Changes = append(diff.Changes, compareListeners(old, current)...)
+	diff.Changes = append(diff.Changes, compareExecutables(old.Processes, current.Processes)...)
+	return diff
+}
+
+func compareUsers(old []users.User, current []users.User) (changes []Change) {
+	old_users := make(map[string]users.User)
+	for _, u := range old {
+		old_users[u.Username] = u
+	}
+	new_users := make(map[string]users.User)
+	for _, u := range current {
+		new_users[u.Username] = u
+	}
+
+	for _, u := range current {
+		o, exists := old_users[u.Username]
+		if !exists {
+			changes = append(changes, Change{
+				Kind:    UserAdded,
+				Subject: u.Username,
+				Detail:  fmt.Sprintf("uid %d, shell %s", u.Uid, u.Shell),
+			})
+			continue
+		}
+		fields := []struct{ name, old, current string }{
+			{"uid", fmt.Sprint(o.Uid), fmt.Sprint(u.Uid)},
+			{"gid", fmt.Sprint(o.Gid), fmt.Sprint(u.Gid)},
+			{"home", o.Home, u.Home},
+			{"shell", o.Shell, u.Shell},
+		}
+		// Without root /etc/shadow can't be read, the hashes are only comparable
+		// when both snapshots have them
+		if o.Password.Username != "" && u.Password.Username != "" {
+			fields = append(fields, struct{ name, old, current string }{"password", o.Password.Password, u.Password.Password})
+		}
+		for _, f := range fields {
+			if f.old != f.current {
+				changes = append(changes, Change{Kind: UserChanged, Subject: u.Username, Field: f.name, Old: f.old, New: f.current})
+			}
+		}
+	}
+	for _, u := range old {
+		if _, exists := new_users[u.Username]; !exists {
+			changes = append(changes, Change{Kind: UserRemoved, Subject: u.Username, Detail: fmt.Sprintf("uid %d", u.Uid)})
+		}
+	}
+	return changes
+}
+
+func compareGroups(old []users.Group, current []users.Group) (changes []Change) {
+	old_groups := make(map[string]users.Group)
+	for _, g := range old {
+		old_groups[g.Name] = g
+	}
+	new_groups := make(map[string]users.Group)
+	for _, g := range current {
+		new_groups[g.Name] = g
+	}
+
+	for _, g := range current {
+		o, exists := old_groups[g.Name]
+		if !exists {
+			changes = append(changes, Change{
+				Kind:    GroupAdded,
+				Subject: g.Name,
+				Detail:  fmt.Sprintf("gid %d, members %v", g.Id, g.Members),
+			})
+			continue
+		}
+		for _, m := range g.Members {
+			if !slices.Contains(o.Members, m) {
+				changes = append(changes, Change{Kind: GroupMemberAdded, Subject: g.Name, New: m})
+			}
+		}
+		for _, m := range o.Members {
+			if !slices.Contains(g.Members, m) {
+				changes = append(changes, Change{Kind: GroupMemberRemoved, Subject: g.Name, Old: m})
+			}
+		}
+	}
+	for _, g := range old {
+		if _, exists := new_groups[g.Name]; !exists {
+			changes = append(changes, Change{Kind: GroupRemoved, Subject: g.Name, Detail: fmt.Sprintf("gid %d", g.Id)})
+		}
+	}
+	return changes
+}
+
+// Get listening sockets keyed by protocol and local endpoint
+// along with a description of the processes holding each one
+func listeners(snap Snapshot) map[string]string {
+	owners := make(map[int][]string)
+	for _, p := range snap.Processes {
+		for _, s := range p.Sockets {
+			owners[s.Inode] = append(owners[s.Inode], fmt.Sprintf("%s[%d]", p.Comm, p.Pid))
+		}
+	}
+	found := make(map[string]string)
+	for _, s := range snap.Sockets {
+		if !s.Listening() || s.LocalEndpoint() == "" {
+			continue
+		}
+		found[s.ProtocolName()+" "+s.LocalEndpoint()] = strings.Join(owners[s.Inode], ", ")
+	}
+	return found
+}
+
+func compareListeners(old Snapshot, current Snapshot) (changes []Change) {
+	old_listeners := listeners(old)
+	new_listeners := listeners(current)
+	for _, key := range sortedKeys(new_listeners) {
+		if _, exists := old_listeners[key]; !exists {
+			changes = append(changes, Change{Kind: ListenerAdded, Subject: key, Detail: new_listeners[key]})
+		}
+	}
+	for _, key := range sortedKeys(old_listeners) {
+		if _, exists := new_listeners[key]; !exists {
+			changes = append(changes, Change{Kind: ListenerRemoved, Subject: key, Detail: old_listeners[key]})
+		}
+	}
+	return changes
+}
+
+// Find executables whose hash wasn't seen in the old snapshot
+// Hashes are only compared with hashes made by the same algorithm
+func compareExecutables(old []proc.Process, current []proc.Process) (changes []Change) {
+	known := make(map[string]bool)
+	algorithms := make(map[string]bool)
+	for _, p := range old {
+		known[p.Exesum_alg+":"+p.Exesum] = true
+		algorithms[p.Exesum_alg] = true
+	}
+	if hashed := hashAlgorithms(current); len(hashed) > 0 && !slices.ContainsFunc(hashed, func(alg string) bool { return algorithms[alg] }) {
+		slog.Warn("The snapshots hash executables with different algorithms, new executables can't be found", "old", hashAlgorithms(old), "new", hashed)
+	}
+	added := make(map[string][]proc.Process)
+	for _, p := range current {
+		sum := p.Exesum_alg + ":" + p.Exesum
+		if p.Exesum != "" && algorithms[p.Exesum_alg] && !known[sum] {
+			added[sum] = append(added[sum], p)
+		}
+	}
+	for sum, procs := range added {
+		pids := make([]string, 0)
+		for _, p := range procs {
+			pids = append(pids, fmt.Sprint(p.Pid))
+		}
+		changes = append(changes, Change{
+			Kind:    ExecutableAdded,
+			Subject: procs[0].Exelink,
+			New:     sum,
+			Detail:  "pids " + strings.Join(pids, ","),
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Subject < changes[j].Subject })
+	return changes
+}
+
+// Get the algorithms used to hash the executables of processes
+func hashAlgorithms(procs []proc.Process) []string {
+	algorithms := make([]string, 0)
+	for _, p := range procs {
+		if p.Exesum != "" && !slices.Contains(algorithms, p.Exesum_alg) {
+			algorithms = append(algorithms, p.Exesum_alg)
+		}
+	}
+	return algorithms
+}
+
+// Get the algorithm most executables in the snapshot were hashed with, empty if none were
+func (s Snapshot) HashAlgorithm() string {
+	counts := make(map[string]int)
+	best := ""
+	for _, p := range s.Processes {
+		if p.Exesum == "" {
+			continue
+		}
+		counts[p.Exesum_alg] += 1
+		if counts[p.Exesum_alg] > counts[best] {
+			best = p.Exesum_alg
+		}
+	}
+	return best
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
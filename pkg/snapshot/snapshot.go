@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"seer/pkg/proc"
+	"seer/pkg/users"
+	"sort"
+	"time"
+)
+
+// Increased whenever the snapshot format changes in an incompatible way
+const Version = 1
+
+// The state of the system at a point in time
+type Snapshot struct {
+	Version   int            `json:"version"`
+	Time      time.Time      `json:"time"`
+	Hostname  string         `json:"hostname"`
+	Processes []proc.Process `json:"processes"`
+	Sockets   []proc.Socket  `json:"sockets"`
+	Users     []users.User   `json:"users"`
+	Groups    []users.Group  `json:"groups"`
+}
+
+// Gather the current state of the system
+func Take() Snapshot {
+	snap := Snapshot{Version: Version, Time: time.Now()}
+	snap.Hostname, _ = os.Hostname()
+
+	for _, p := range proc.GetProcesses() {
+		snap.Processes = append(snap.Processes, p)
+	}
+	sort.Slice(snap.Processes, func(i, j int) bool { return snap.Processes[i].Pid < snap.Processes[j].Pid })
+
+	snap.Sockets = proc.GetSockets()
+
+	users_map, err := users.GetUsers()
+	if err != nil {
+		slog.Warn("Failed to get users", "error", err.Error())
+	}
+	for _, u := range users_map {
+		snap.Users = append(snap.Users, u)
+	}
+	sort.Slice(snap.Users, func(i, j int) bool { return snap.Users[i].Uid < snap.Users[j].Uid })
+
+	groups_map, err := users.GetGroups()
+	if err != nil {
+		slog.Warn("Failed to get groups", "error", err.Error())
+	}
+	for _, g := range groups_map {
+		snap.Groups = append(snap.Groups, g)
+	}
+	sort.Slice(snap.Groups, func(i, j int) bool { return snap.Groups[i].Id < snap.Groups[j].Id })
+
+	return snap
+}
+
+// Write the snapshot to path
+// The file is only readable by the owner since it may contain password hashes.
+// It is written to a temporary file that replaces path, so an existing file
+// doesn't keep its mode and readers never see a partial snapshot.
+func (s Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %s", err)
+	}
+	// CreateTemp creates the file with mode 0600
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".seer-snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if close_err := tmp.Close(); err == nil {
+		err = close_err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %s", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %s", err)
+	}
+	return nil
+}
+
+// Read a snapshot written by Save
+func Load(path string) (Snapshot, error) {
+	var snap Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, fmt.Errorf("failed to read snapshot: %s", err)
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("failed to decode snapshot '%s': %s", path, err)
+	}
+	if snap.Version != Version {
+		return snap, fmt.Errorf("unsupported snapshot version %d in '%s' (expected %d)", snap.Version, path, Version)
+	}
+	return snap, nil
+}
@@ -0,0 +1,79 @@
+package sysfs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A read only view of a directory tree such as /proc or /etc
+// Names are slash separated paths relative to the root of the tree, ex. "1/stat"
+type FS interface {
+	fs.ReadDirFS
+	fs.ReadFileFS
+	fs.StatFS
+	// Read the target of a symbolic link without following it
+	ReadLink(name string) (string, error)
+}
+
+type dirFS struct {
+	fs.FS
+	root string
+}
+
+// Get an FS for the directory tree rooted at root
+func Dir(root string) FS {
+	root = filepath.Clean(root)
+	return dirFS{FS: os.DirFS(root), root: root}
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(d.FS, name)
+}
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(d.FS, name)
+}
+
+func (d dirFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(d.FS, name)
+}
+
+func (d dirFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Readlink(filepath.Join(d.root, filepath.FromSlash(name)))
+}
+
+// Get the directory an FS created with Dir is rooted at
+func Root(f FS) string {
+	if d, ok := f.(dirFS); ok {
+		return d.root
+	}
+	return ""
+}
+
+const (
+	DefaultProcRoot = "/proc"
+	DefaultEtcRoot  = "/etc"
+)
+
+// The sources used by every reader in seer
+// These can be pointed at a snapshot, a container rootfs or test fixtures
+var (
+	Proc FS = Dir(DefaultProcRoot)
+	Etc  FS = Dir(DefaultEtcRoot)
+)
+
+// True if Proc is the procfs of the running system
+// Information that doesn't come from /proc (ex. sock_diag) is only
+// gathered when this is true
+func LiveProc() bool {
+	return Root(Proc) == DefaultProcRoot
+}
+
+// True if Etc is the /etc of the running system
+func LiveEtc() bool {
+	return Root(Etc) == DefaultEtcRoot
+}
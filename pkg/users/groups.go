@@ -2,28 +2,29 @@ package users
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
+	"seer/pkg/sysfs"
 	"strconv"
 	"strings"
 )
 
 type GPassword struct {
 	// From /etc/gshadow
-	Group    string   // The name of the group this password is for
-	Password string   // Encrypted password
-	Admins   []string // List of group admin usernames
-	Members  []string // List of users who can access the group without the password
+	Group    string   `json:"-"`       // The name of the group this password is for
+	Password string   `json:"hash"`    // Encrypted password
+	Admins   []string `json:"admins"`  // List of group admin usernames
+	Members  []string `json:"members"` // List of users who can access the group without the password
 }
 
 type Group struct {
 	// From /etc/group
-	Name     string
-	Password GPassword // Group password (almost never used)
-	Id       int       // Group id
-	Members  []string  // List of users in the group
+	Name     string    `json:"name"`
+	Password GPassword `json:"password"` // Group password (almost never used)
+	Id       int       `json:"gid"`      // Group id
+	Members  []string  `json:"members"`  // List of users in the group
 }
 
 func (g Group) Describe() string {
@@ -38,8 +39,45 @@ func (g Group) Describe() string {
 		g.Members)
 }
 
+func (g Group) Columns() []string {
+	return []string{"GROUP", "GID", "MEMBERS"}
+}
+
+func (g Group) Row() []string {
+	return []string{g.Name, strconv.Itoa(g.Id), strings.Join(g.Members, ",")}
+}
+
+// Groups without a gshadow entry are encoded with a null password
+func (g Group) MarshalJSON() ([]byte, error) {
+	type group Group
+	var password *GPassword
+	if g.Password.Group != "" {
+		password = &g.Password
+	}
+	return json.Marshal(struct {
+		group
+		Password *GPassword `json:"password"`
+	}{group(g), password})
+}
+
+func (g *Group) UnmarshalJSON(data []byte) error {
+	type group Group
+	aux := struct {
+		*group
+		Password *GPassword `json:"password"`
+	}{group: (*group)(g)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Password != nil {
+		g.Password = *aux.Password
+		g.Password.Group = g.Name
+	}
+	return nil
+}
+
 func GetGPasswords() (map[string]GPassword, error) {
-	gshadow, err := os.Open("/etc/gshadow")
+	gshadow, err := sysfs.Etc.Open("gshadow")
 	if err != nil {
 		return nil, errors.New("failed to read /etc/gshadow")
 	}
@@ -87,7 +125,7 @@ func GetGroups() (map[string]Group, error) {
 		gpasswds = make(map[string]GPassword)
 	}
 
-	group_db, err := os.Open("/etc/group")
+	group_db, err := sysfs.Etc.Open("group")
 	if err != nil {
 		return nil, errors.New("failed to read /etc/group")
 	}
@@ -3,7 +3,7 @@ package users
 import (
 	"bufio"
 	"errors"
-	"os"
+	"seer/pkg/sysfs"
 	"strconv"
 	"strings"
 	"time"
@@ -11,14 +11,14 @@ import (
 
 type Password struct {
 	// From /etc/shadow, usually requires root access
-	Username          string
-	Password          string // Encrypted password, "*", or "!"
-	Last_change       int    // Date when the password was last changed, in days since epoch
-	Min_age           int    // Number of days before the password can be changed, typically 0
-	Max_age           int    // Number of days after a reset when the password expires, typically 99999
-	Warn_period       int    // Number of days before password expiration to start warning the user
-	Inactivity_period int    // Number of days after password expiration when the account is diabled, typically blank
-	Expiration_date   int    // Date when the password expires, in days since epoch
+	Username          string `json:"-"`
+	Password          string `json:"hash"`              // Encrypted password, "*", or "!"
+	Last_change       int    `json:"last_change"`       // Date when the password was last changed, in days since epoch
+	Min_age           int    `json:"min_age"`           // Number of days before the password can be changed, typically 0
+	Max_age           int    `json:"max_age"`           // Number of days after a reset when the password expires, typically 99999
+	Warn_period       int    `json:"warn_period"`       // Number of days before password expiration to start warning the user
+	Inactivity_period int    `json:"inactivity_period"` // Number of days after password expiration when the account is diabled, typically blank
+	Expiration_date   int    `json:"expiration_date"`   // Date when the password expires, in days since epoch
 }
 
 func (p Password) IsExpired() bool {
@@ -32,7 +32,7 @@ func (p Password) IsExpired() bool {
 }
 
 func GetPasswords() (map[string]Password, error) {
-	shadow, err := os.Open("/etc/shadow")
+	shadow, err := sysfs.Etc.Open("shadow")
 	if err != nil {
 		return nil, errors.New("failed to read /etc/shadow")
 	}
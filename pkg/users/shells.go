@@ -3,12 +3,12 @@ package users
 import (
 	"bufio"
 	"errors"
-	"os"
+	"seer/pkg/sysfs"
 	"strings"
 )
 
 func GetShells() (shells []string, err error) {
-	shell_db, err := os.Open("/etc/shells")
+	shell_db, err := sysfs.Etc.Open("shells")
 	if err != nil {
 		return nil, errors.New("failed to read /etc/shells")
 	}
@@ -2,30 +2,35 @@ package users
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"os/exec"
+	"seer/pkg/sysfs"
 	"strconv"
 	"strings"
 )
 
 type User struct {
 	// From /etc/passwd
-	Username string
-	Password Password
-	Uid      int    // User id
-	Gecos    string // Comma separated user details
-	Home     string // Home directory
-	Shell    string // Login shell
+	Username string   `json:"name"`
+	Password Password `json:"password"`
+	Uid      int      `json:"uid"`   // User id
+	Gid      int      `json:"gid"`   // Primary group id, set even when the group doesn't exist
+	Gecos    string   `json:"gecos"` // Comma separated user details
+	Home     string   `json:"home"`  // Home directory
+	Shell    string   `json:"shell"` // Login shell
 
 	// Groups that the user is a member of
-	PrimaryGroup    Group
-	SecondaryGroups []Group
+	PrimaryGroup    Group   `json:"primary_group"`
+	SecondaryGroups []Group `json:"secondary_groups"`
 }
 
 func (u User) Expire() error {
+	if !sysfs.LiveEtc() {
+		return errors.New("refusing to modify users read from an alternate /etc")
+	}
 	if !u.Password.IsExpired() {
 		cmd := exec.Command("usermod", "-e", "1", u.Username)
 		err := cmd.Run()
@@ -37,6 +42,9 @@ func (u User) Expire() error {
 }
 
 func (u User) UnExpire() error {
+	if !sysfs.LiveEtc() {
+		return errors.New("refusing to modify users read from an alternate /etc")
+	}
 	if u.Password.IsExpired() {
 		cmd := exec.Command("usermod", "-e", "99999", u.Username)
 		err := cmd.Run()
@@ -82,6 +90,68 @@ func (u User) Describe() string {
 		u.Password.IsExpired())
 }
 
+func (u User) Columns() []string {
+	return []string{"USER", "UID", "GROUP", "HOME", "SHELL", "PASSWORD", "EXPIRED"}
+}
+
+func (u User) Row() []string {
+	return []string{
+		u.Username,
+		strconv.Itoa(u.Uid),
+		u.PrimaryGroup.Name,
+		u.Home,
+		u.Shell,
+		u.Password.Password,
+		strconv.FormatBool(u.Password.IsExpired()),
+	}
+}
+
+// Users are encoded with their groups by name, the full group records are
+// available from `seer group`. Users without a shadow entry (usually due to
+// missing permissions) are encoded with a null password.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	var password *Password
+	if u.Password.Username != "" {
+		password = &u.Password
+	}
+	secondary_groups := make([]string, 0)
+	for _, g := range u.SecondaryGroups {
+		secondary_groups = append(secondary_groups, g.Name)
+	}
+	return json.Marshal(struct {
+		user
+		Password        *Password `json:"password"`
+		PrimaryGroup    string    `json:"primary_group"`
+		SecondaryGroups []string  `json:"secondary_groups"`
+		Expired         bool      `json:"expired"`
+	}{user(u), password, u.PrimaryGroup.Name, secondary_groups, u.Password.IsExpired()})
+}
+
+func (u *User) UnmarshalJSON(data []byte) error {
+	type user User
+	aux := struct {
+		*user
+		Password        *Password `json:"password"`
+		PrimaryGroup    string    `json:"primary_group"`
+		SecondaryGroups []string  `json:"secondary_groups"`
+	}{user: (*user)(u)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Password != nil {
+		u.Password = *aux.Password
+		u.Password.Username = u.Username
+	}
+	// Only the group names are available, see the group records for the rest
+	u.PrimaryGroup = Group{Name: aux.PrimaryGroup}
+	u.SecondaryGroups = make([]Group, 0)
+	for _, g := range aux.SecondaryGroups {
+		u.SecondaryGroups = append(u.SecondaryGroups, Group{Name: g})
+	}
+	return nil
+}
+
 func GetUsers() (map[string]User, error) {
 	passwords, err := GetPasswords()
 	if err != nil {
@@ -100,7 +170,7 @@ func GetUsers() (map[string]User, error) {
 		}
 	}
 
-	passwd, err := os.Open("/etc/passwd")
+	passwd, err := sysfs.Etc.Open("passwd")
 	if err != nil {
 		return nil, errors.New("failed to read /etc/passwd")
 	}
@@ -138,6 +208,7 @@ func GetUsers() (map[string]User, error) {
 			Username:        user_data[0],
 			Password:        passwords[user_data[0]],
 			Uid:             user_id,
+			Gid:             user_gid,
 			Gecos:           user_data[4],
 			Home:            user_data[5],
 			Shell:           user_data[6],
@@ -0,0 +1,140 @@
+package users
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"seer/pkg/sysfs"
+)
+
+func TestMain(m *testing.M) {
+	sysfs.Etc = sysfs.Dir("testdata/etc")
+	os.Exit(m.Run())
+}
+
+func TestGetPasswords(t *testing.T) {
+	passwords, err := GetPasswords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		want Password
+	}{
+		{"root", Password{Username: "root", Password: "$6$salt$hash", Last_change: 19800, Min_age: 0, Max_age: 99999, Warn_period: 7, Inactivity_period: -1, Expiration_date: -1}},
+		{"daemon", Password{Username: "daemon", Password: "*", Last_change: 19800, Min_age: 0, Max_age: 99999, Warn_period: 7, Inactivity_period: -1, Expiration_date: -1}},
+		{"alice", Password{Username: "alice", Password: "$y$j9T$salt$hash", Last_change: 19850, Min_age: 1, Max_age: 90, Warn_period: 14, Inactivity_period: 30, Expiration_date: 20000}},
+		{"bob", Password{Username: "bob", Password: "!", Last_change: 19851, Min_age: -1, Max_age: -1, Warn_period: -1, Inactivity_period: -1, Expiration_date: -1}},
+	}
+	if len(passwords) != len(tests) {
+		t.Errorf("got %d passwords, want %d", len(passwords), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := passwords[tt.name]; got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGroups(t *testing.T) {
+	groups, err := GetGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		id       int
+		members  []string
+		hash     string
+		admins   []string
+		gmembers []string
+	}{
+		{"root", 0, []string{}, "*", []string{}, []string{}},
+		{"daemon", 1, []string{}, "", nil, nil},
+		{"sudo", 27, []string{"alice", "bob"}, "*", []string{"admin"}, []string{"alice", "bob"}},
+		{"alice", 1000, []string{}, "!", []string{}, []string{}},
+		{"bob", 1002, []string{}, "", nil, nil},
+	}
+	if len(groups) != len(tests) {
+		t.Errorf("got %d groups, want %d", len(groups), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, exists := groups[tt.name]
+			if !exists {
+				t.Fatalf("group not found")
+			}
+			if g.Id != tt.id || !slices.Equal(g.Members, tt.members) {
+				t.Errorf("got gid %d members %v, want gid %d members %v", g.Id, g.Members, tt.id, tt.members)
+			}
+			if g.Password.Password != tt.hash || !slices.Equal(g.Password.Admins, tt.admins) || !slices.Equal(g.Password.Members, tt.gmembers) {
+				t.Errorf("got gshadow %+v, want hash %q admins %v members %v", g.Password, tt.hash, tt.admins, tt.gmembers)
+			}
+		})
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	users, err := GetUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		uid       int
+		gecos     string
+		home      string
+		shell     string
+		hash      string
+		primary   string
+		secondary []string
+	}{
+		{"root", 0, "root", "/root", "/bin/bash", "$6$salt$hash", "root", nil},
+		{"daemon", 1, "daemon", "/usr/sbin", "/usr/sbin/nologin", "*", "daemon", nil},
+		{"alice", 1000, "Alice,,,", "/home/alice", "/bin/bash", "$y$j9T$salt$hash", "alice", []string{"sudo"}},
+		{"bob", 1002, "", "/home/bob", "/bin/sh", "!", "bob", []string{"sudo"}},
+	}
+	if len(users) != len(tests) {
+		t.Errorf("got %d users, want %d", len(users), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, exists := users[tt.name]
+			if !exists {
+				t.Fatalf("user not found")
+			}
+			if u.Uid != tt.uid || u.Gecos != tt.gecos || u.Home != tt.home || u.Shell != tt.shell {
+				t.Errorf("got uid %d gecos %q home %q shell %q, want uid %d gecos %q home %q shell %q",
+					u.Uid, u.Gecos, u.Home, u.Shell, tt.uid, tt.gecos, tt.home, tt.shell)
+			}
+			if u.Password.Password != tt.hash {
+				t.Errorf("got hash %q, want %q", u.Password.Password, tt.hash)
+			}
+			if u.PrimaryGroup.Name != tt.primary {
+				t.Errorf("got primary group %q, want %q", u.PrimaryGroup.Name, tt.primary)
+			}
+			var secondary []string
+			for _, g := range u.SecondaryGroups {
+				secondary = append(secondary, g.Name)
+			}
+			if !reflect.DeepEqual(secondary, tt.secondary) {
+				t.Errorf("got secondary groups %v, want %v", secondary, tt.secondary)
+			}
+		})
+	}
+}
+
+func TestGetShells(t *testing.T) {
+	shells, err := GetShells()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/bin/sh", "/bin/bash", "/usr/bin/bash"}
+	if !slices.Equal(shells, want) {
+		t.Errorf("got %v, want %v", shells, want)
+	}
+}
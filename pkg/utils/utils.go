@@ -8,3 +8,18 @@ func Confirm() bool {
 	fmt.Scanln(&input)
 	return input == "yes"
 }
+
+// Format a number of bytes with a binary unit, ex. 1.5M
+func FormatBytes(n uint64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n) / 1024
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f%c", value, units[i])
+}
@@ -0,0 +1,89 @@
+package verify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Labels given to executables
+const (
+	KnownGood = "known-good"
+	KnownBad  = "known-bad"
+	Unknown   = "unknown"
+)
+
+// A set of hashes with optional names, ex. the name of the malware family
+type HashList map[string]string
+
+// Read a hash list
+// Each line holds a hash optionally followed by a name, which also accepts the output
+// of sha256sum and friends. Blank lines and lines starting with # are ignored.
+func LoadHashList(path string) (HashList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash list: %s", err)
+	}
+	defer f.Close()
+
+	list := make(HashList)
+	scanner := bufio.NewScanner(f)
+	line_num := 0
+	for scanner.Scan() {
+		line_num += 1
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		sum := strings.ToLower(fields[0])
+		if !validHash(sum) {
+			return nil, fmt.Errorf("invalid hash '%s' on line %d of '%s'", fields[0], line_num, path)
+		}
+		// sha256sum marks binary files with a * before the name
+		list[sum] = strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(line, fields[0])), "*")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hash list: %s", err)
+	}
+	return list, nil
+}
+
+// True for hex strings with the length of an md5, sha1 or sha256 hash
+func validHash(sum string) bool {
+	if len(sum) != 32 && len(sum) != 40 && len(sum) != 64 {
+		return false
+	}
+	for _, c := range sum {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the algorithm that produced a hash from its length
+func HashAlgorithm(sum string) string {
+	switch len(sum) {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 64:
+		return "sha256"
+	}
+	return ""
+}
+
+// Label a hash using the allow and deny lists
+// A hash in both lists is known-bad
+func Label(sum string, allow HashList, deny HashList) (string, string) {
+	if name, exists := deny[sum]; exists {
+		return KnownBad, name
+	}
+	if name, exists := allow[sum]; exists {
+		return KnownGood, name
+	}
+	return Unknown, ""
+}
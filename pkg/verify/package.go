@@ -0,0 +1,128 @@
+package verify
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"seer/pkg/sysfs"
+)
+
+// Package managers the checksums of installed files are read from
+const (
+	ManagerDpkg = "dpkg"
+	ManagerRpm  = "rpm"
+)
+
+// The checksum a package manager recorded for an installed file
+type PackageSum struct {
+	Manager string
+	Alg     string
+	Sum     string
+}
+
+// Checksums of files installed by the system's package managers
+// dpkg databases are read up front, rpm is queried per file since its database isn't plain text
+type PackageDB struct {
+	dpkg map[string]string // Path to md5
+	rpm  bool
+
+	lock      sync.Mutex
+	rpm_cache map[string]*PackageSum
+}
+
+// Get the directory the dpkg database is in
+// It sits next to the etc root so a container rootfs or mounted image is checked against its own packages
+func dpkgInfoDir() string {
+	return filepath.Join(filepath.Dir(sysfs.Root(sysfs.Etc)), "var", "lib", "dpkg", "info")
+}
+
+// Load the checksums of installed packages
+// rpm is only queried when reading the running system since it always uses the host's database
+func LoadPackageDB() *PackageDB {
+	db := &PackageDB{dpkg: make(map[string]string), rpm_cache: make(map[string]*PackageSum)}
+
+	dir := dpkgInfoDir()
+	sums, _ := filepath.Glob(filepath.Join(dir, "*.md5sums"))
+	for _, name := range sums {
+		f, err := os.Open(name)
+		if err != nil {
+			slog.Debug("Failed to read dpkg checksums", "file", name, "error", err.Error())
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			// Lines are "<md5>  <path without the leading slash>"
+			sum, path, found := strings.Cut(scanner.Text(), "  ")
+			if found {
+				db.dpkg["/"+path] = sum
+			}
+		}
+		f.Close()
+	}
+
+	if sysfs.LiveEtc() {
+		if _, err := exec.LookPath("rpm"); err == nil {
+			db.rpm = true
+		}
+	}
+	slog.Debug("Loaded package checksums", "dpkg", len(db.dpkg), "rpm", db.rpm)
+	return db
+}
+
+// True if no package manager was found
+func (db *PackageDB) Empty() bool {
+	return len(db.dpkg) == 0 && !db.rpm
+}
+
+// Get the recorded checksum of an installed file, nil if no package owns it
+func (db *PackageDB) Lookup(path string) *PackageSum {
+	// On merged /usr systems /bin/ls runs as /usr/bin/ls but older packages list /bin/ls
+	candidates := []string{path}
+	if trimmed, found := strings.CutPrefix(path, "/usr"); found {
+		candidates = append(candidates, trimmed)
+	} else {
+		candidates = append(candidates, "/usr"+path)
+	}
+	for _, c := range candidates {
+		if sum, exists := db.dpkg[c]; exists {
+			return &PackageSum{Manager: ManagerDpkg, Alg: "md5", Sum: sum}
+		}
+	}
+	if db.rpm {
+		return db.rpmLookup(path)
+	}
+	return nil
+}
+
+// Query rpm for the digest of a file, results are cached since many processes share an executable
+func (db *PackageDB) rpmLookup(path string) *PackageSum {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	if sum, exists := db.rpm_cache[path]; exists {
+		return sum
+	}
+
+	var result *PackageSum
+	out, err := exec.Command("rpm", "--dump", "-qf", path).Output()
+	if err != nil {
+		slog.Debug("File not owned by an rpm package", "path", path, "error", err.Error())
+	}
+	// Lines are "<path> <size> <mtime> <digest> <mode> <owner> <group> ..."
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != path {
+			continue
+		}
+		if alg := HashAlgorithm(fields[3]); alg != "" {
+			result = &PackageSum{Manager: ManagerRpm, Alg: alg, Sum: fields[3]}
+		}
+		break
+	}
+	db.rpm_cache[path] = result
+	return result
+}
@@ -0,0 +1,138 @@
+package verify
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"seer/pkg/proc"
+)
+
+// Results of comparing an executable against the checksum of its package
+const (
+	PackageOk       = "ok"
+	PackageModified = "modified"
+	PackageNone     = "unpackaged"
+)
+
+// The verdict for the executable of a process
+type Result struct {
+	Pid     int    `json:"pid"`
+	Comm    string `json:"comm"`
+	User    string `json:"user"`
+	Exe     string `json:"exe"`
+	Hash    string `json:"hash"`              // alg:sum
+	Label   string `json:"label"`             // known-good, known-bad or unknown
+	Name    string `json:"name,omitempty"`    // The name given to the hash in the matching list
+	Package string `json:"package,omitempty"` // ok, modified or unpackaged, empty when packages weren't checked
+	Manager string `json:"manager,omitempty"` // The package manager that owns the executable
+}
+
+func (r Result) String() string {
+	label := r.Label
+	if r.Name != "" {
+		label = fmt.Sprintf("%s (%s)", r.Label, r.Name)
+	}
+	pkg := ""
+	switch r.Package {
+	case PackageOk:
+		pkg = fmt.Sprintf(" [%s: ok]", r.Manager)
+	case PackageModified:
+		pkg = fmt.Sprintf(" [WARNING: differs from %s package]", r.Manager)
+	case PackageNone:
+		pkg = " [unpackaged]"
+	}
+	return fmt.Sprintf("[%d] %s %s %s %s%s\n", r.Pid, r.Comm, r.Exe, r.Hash, label, pkg)
+}
+
+func (r Result) Columns() []string {
+	return []string{"PID", "COMM", "USER", "EXE", "HASH", "LABEL", "NAME", "PACKAGE"}
+}
+
+func (r Result) Row() []string {
+	pkg := r.Package
+	if r.Manager != "" {
+		pkg = fmt.Sprintf("%s (%s)", r.Package, r.Manager)
+	}
+	return []string{strconv.Itoa(r.Pid), r.Comm, r.User, r.Exe, r.Hash, r.Label, r.Name, pkg}
+}
+
+// Get the algorithms of the hashes in the lists
+func listAlgorithms(lists ...HashList) []string {
+	algs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for sum := range list {
+			alg := HashAlgorithm(sum)
+			if !seen[alg] {
+				seen[alg] = true
+				algs = append(algs, alg)
+			}
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// Label the executable of every process using the allow and deny lists
+// Lists may mix algorithms, executables are hashed with each one used
+// When db is not nil the executable is also compared against the checksum of its package
+func Verify(procs map[int]proc.Process, allow HashList, deny HashList, db *PackageDB) []Result {
+	algs := listAlgorithms(allow, deny)
+
+	pids := make([]int, 0, len(procs))
+	for pid := range procs {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+
+	results := make([]Result, 0)
+	for _, pid := range pids {
+		p := procs[pid]
+		// Kernel threads and processes whose executable can't be read have nothing to verify
+		if p.Exesum == "" {
+			continue
+		}
+		r := Result{
+			Pid: p.Pid, Comm: p.Comm, User: p.User.Username, Exe: p.Exelink,
+			Hash: fmt.Sprintf("%s:%s", p.Exesum_alg, p.Exesum), Label: Unknown,
+		}
+
+		sums := map[string]string{p.Exesum_alg: p.Exesum}
+		hash := func(alg string) string {
+			if sum, exists := sums[alg]; exists {
+				return sum
+			}
+			sum, err := p.ExeHash(alg)
+			if err != nil {
+				slog.Debug("Failed to hash process executable", "process", p.Pid, "error", err.Error())
+			}
+			sums[alg] = sum
+			return sum
+		}
+
+		// A match in the deny list with any algorithm wins over the allow list
+		for _, alg := range algs {
+			if label, name := Label(hash(alg), allow, deny); label == KnownBad {
+				r.Label, r.Name = label, name
+				break
+			} else if label == KnownGood {
+				r.Label, r.Name = label, name
+			}
+		}
+
+		if db != nil {
+			r.Package = PackageNone
+			if pkg := db.Lookup(p.Exelink); pkg != nil {
+				r.Manager = pkg.Manager
+				r.Package = PackageModified
+				if hash(pkg.Alg) == pkg.Sum {
+					r.Package = PackageOk
+				}
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,239 @@
+package watch
+
+import (
+	"crypto/md5"
+	"fmt"
+	"seer/pkg/proc"
+	"seer/pkg/snapshot"
+	"seer/pkg/sysfs"
+	"seer/pkg/users"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of events
+const (
+	ProcessStart       = "process_start"
+	ProcessExit        = "process_exit"
+	ProcessExec        = "process_exec"
+	ListenerAdded      = "listener_added"
+	OutboundConnection = "outbound_connection"
+	UserDBChanged      = "userdb_changed"
+)
+
+var Kinds = []string{ProcessStart, ProcessExit, ProcessExec, ListenerAdded, OutboundConnection, UserDBChanged}
+
+type Event struct {
+	Time    time.Time    `json:"time"`
+	Kind    string       `json:"kind"`
+	Pid     int          `json:"pid,omitempty"`
+	Ppid    int          `json:"ppid,omitempty"`
+	User    string       `json:"user,omitempty"`
+	Exe     string       `json:"exe,omitempty"`
+	Cmdline string       `json:"cmdline,omitempty"`
+	Socket  *proc.Socket `json:"socket,omitempty"`
+	Detail  string       `json:"detail,omitempty"`
+}
+
+func (e Event) String() string {
+	line := fmt.Sprintf("%s %s", e.Time.Format(time.RFC3339), e.Kind)
+	if e.Pid != 0 {
+		line += fmt.Sprintf(" [%d] %s (%s) %s", e.Pid, e.Exe, e.Cmdline, e.User)
+	}
+	if e.Socket != nil {
+		line += " " + strings.TrimSuffix(e.Socket.String(), "\n")
+	}
+	if e.Detail != "" {
+		line += " " + e.Detail
+	}
+	return line + "\n"
+}
+
+// Processes are keyed by pid and start time so a reused pid is seen as a new process
+type procKey struct {
+	Pid       int
+	Starttime uint64
+}
+
+// Connections are keyed by their endpoints since inodes change if a socket is recreated
+type connKey struct {
+	Protocol string
+	Local    string
+	Remote   string
+}
+
+type state struct {
+	procs       map[procKey]proc.Process
+	pids        map[int]proc.Process // The same processes keyed by pid
+	index       proc.SocketIndex     // The holders of each socket
+	listeners   map[connKey]proc.Socket
+	connections map[connKey]proc.Socket
+	userdb      string // Fingerprint of the user and group databases
+	snap        snapshot.Snapshot
+}
+
+// Polls the system and reports the differences between each poll
+type Watcher struct {
+	// Only report process and socket events for processes matching the filter
+	Filter proc.Filter
+
+	prev *state
+}
+
+// Files that make up the user database
+var userDBFiles = []string{"passwd", "shadow", "group", "gshadow"}
+
+func userDBFingerprint() string {
+	h := md5.New()
+	for _, f := range userDBFiles {
+		data, _ := sysfs.Etc.ReadFile(f)
+		h.Write([]byte(f))
+		h.Write(data)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func readState(prev *state) *state {
+	s := &state{
+		procs:       make(map[procKey]proc.Process),
+		listeners:   make(map[connKey]proc.Socket),
+		connections: make(map[connKey]proc.Socket),
+		userdb:      userDBFingerprint(),
+	}
+	// Sockets are read before processes so the owners of new sockets are found
+	sockets := proc.GetSockets()
+	s.pids = proc.GetProcesses()
+	for _, p := range s.pids {
+		s.procs[procKey{p.Pid, p.Starttime}] = p
+	}
+	s.index = proc.IndexSockets(s.pids)
+
+	listening_ports := make(map[string]bool)
+	for _, sock := range sockets {
+		if sock.Listening() {
+			s.listeners[connKey{sock.ProtocolName(), sock.LocalEndpoint(), ""}] = sock
+			listening_ports[fmt.Sprintf("%s:%d", sock.Protocol, sock.Local_port)] = true
+		}
+	}
+	for _, sock := range sockets {
+		// Established connections not accepted by a local listener were initiated locally,
+		// connected udp sockets report CONNECTED rather than ESTABLISHED
+		if sock.Family != "inet" && sock.Family != "inet6" {
+			continue
+		}
+		outbound := sock.State == proc.ESTABLISHED || sock.State == proc.SYN_SENT || sock.State == proc.CONNECTED
+		if outbound && !listening_ports[fmt.Sprintf("%s:%d", sock.Protocol, sock.Local_port)] {
+			s.connections[connKey{sock.ProtocolName(), sock.LocalEndpoint(), sock.RemoteEndpoint()}] = sock
+		}
+	}
+
+	// Only re-read the users and groups when the files changed
+	if prev != nil && prev.userdb == s.userdb {
+		s.snap = prev.snap
+	} else {
+		s.snap = snapshot.Snapshot{}
+		users_map, _ := users.GetUsers()
+		for _, u := range users_map {
+			s.snap.Users = append(s.snap.Users, u)
+		}
+		groups_map, _ := users.GetGroups()
+		for _, g := range groups_map {
+			s.snap.Groups = append(s.snap.Groups, g)
+		}
+	}
+	return s
+}
+
+func processEvent(kind string, p proc.Process, now time.Time) Event {
+	return Event{
+		Time:    now,
+		Kind:    kind,
+		Pid:     p.Pid,
+		Ppid:    p.Ppid,
+		User:    p.User.Username,
+		Exe:     p.Exelink,
+		Cmdline: p.Args(),
+	}
+}
+
+// Find the process holding a socket
+func (s *state) owner(inode int) (proc.Process, bool) {
+	for _, o := range s.index.Owners[inode] {
+		if p, exists := s.pids[o.Pid]; exists {
+			return p, true
+		}
+	}
+	return proc.Process{}, false
+}
+
+func (w *Watcher) matches(p proc.Process) bool {
+	return w.Filter == nil || w.Filter.Match(p)
+}
+
+// Events for new sockets in current that aren't in prev
+func (w *Watcher) socketEvents(kind string, prev map[connKey]proc.Socket, current map[connKey]proc.Socket, st *state, now time.Time) (events []Event) {
+	for key, sock := range current {
+		if _, exists := prev[key]; exists {
+			continue
+		}
+		event := Event{Time: now, Kind: kind}
+		if p, found := st.owner(sock.Inode); found {
+			if !w.matches(p) {
+				continue
+			}
+			event = processEvent(kind, p, now)
+		} else if w.Filter != nil {
+			continue
+		}
+		event.Socket = &sock
+		events = append(events, event)
+	}
+	return events
+}
+
+// Read the current state of the system and get the events since the last poll
+// The first poll only records the initial state
+func (w *Watcher) Poll() []Event {
+	current := readState(w.prev)
+	prev := w.prev
+	w.prev = current
+	if prev == nil {
+		return nil
+	}
+
+	now := time.Now()
+	events := make([]Event, 0)
+
+	for key, p := range current.procs {
+		old, exists := prev.procs[key]
+		if !w.matches(p) {
+			continue
+		}
+		if !exists {
+			events = append(events, processEvent(ProcessStart, p, now))
+		} else if p.Exelink != "" && (old.Exelink != p.Exelink || old.Cmdline != p.Cmdline) {
+			// Zombies lose their exe and cmdline so only changes to a new exe count as an exec
+			event := processEvent(ProcessExec, p, now)
+			event.Detail = fmt.Sprintf("previously %s (%s)", old.Exelink, old.Args())
+			events = append(events, event)
+		}
+	}
+	for key, p := range prev.procs {
+		if _, exists := current.procs[key]; !exists && w.matches(p) {
+			events = append(events, processEvent(ProcessExit, p, now))
+		}
+	}
+
+	events = append(events, w.socketEvents(ListenerAdded, prev.listeners, current.listeners, current, now)...)
+	events = append(events, w.socketEvents(OutboundConnection, prev.connections, current.connections, current, now)...)
+
+	if prev.userdb != current.userdb {
+		for _, c := range snapshot.Compare(prev.snap, current.snap).Changes {
+			events = append(events, Event{Time: now, Kind: UserDBChanged, Detail: strings.TrimSpace(c.String())})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Pid < events[j].Pid })
+	return events
+}